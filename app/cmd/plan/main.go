@@ -0,0 +1,96 @@
+// Command plan prints the changes the app operator would perform on the
+// children of an App, without writing anything.
+//
+//	plan -namespace default -name app-sample   plans the live spec
+//	plan -f app.yaml                           plans the spec of the file
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	appv1 "multi.ch/app/api/v1"
+	"multi.ch/app/internal/controller"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(appv1.AddToScheme(scheme))
+}
+
+func main() {
+	var file, name, namespace string
+	flag.StringVar(&file, "f", "", "A file containing the proposed App, its spec replaces the live one.")
+	flag.StringVar(&name, "name", "", "The name of the App to plan.")
+	flag.StringVar(&namespace, "namespace", "default", "The namespace of the App to plan.")
+	flag.Parse()
+
+	if err := run(context.Background(), file, name, namespace); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, file, name, namespace string) error {
+	var proposed *appv1.App
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		proposed = &appv1.App{}
+		if err := yaml.Unmarshal(data, proposed); err != nil {
+			return err
+		}
+
+		name = proposed.Name
+		if proposed.Namespace != "" {
+			namespace = proposed.Namespace
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("either -f or -name is required")
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	var app appv1.App
+	err = cluster.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &app)
+	switch {
+	case apierrors.IsNotFound(err) && proposed != nil:
+		app = *proposed
+		app.Namespace = namespace
+	case err != nil:
+		return err
+	case proposed != nil:
+		app.Spec = proposed.Spec
+	}
+
+	plan, err := controller.Plan(ctx, cluster, &app)
+	if err != nil {
+		return err
+	}
+
+	return plan.Write(os.Stdout)
+}
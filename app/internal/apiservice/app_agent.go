@@ -30,6 +30,10 @@ func (r *AppAgentRepository) Register(s *fuego.Server) {
 	fuego.Get(agentGroup, "", r.DiscoveryGroup)
 	fuego.Get(versionedAgentGroup, "", r.DiscoveryV1ResourceList)
 	fuego.Get(namespacedAgentGroup, "", r.DoAction)
+
+	planGroup := fuego.Group(versionedAgentGroup, "/namespaces/{namespace}/apps/{resource}/plan")
+	fuego.Get(planGroup, "", r.Plan)
+	fuego.Post(planGroup, "", r.PlanSpec)
 }
 
 func (r *AppAgentRepository) DoAction(c fuego.ContextNoBody) (*APIResponse[string], error) {
@@ -51,6 +51,12 @@ func (r *AppAgentRepository) DiscoveryV1ResourceList(c fuego.ContextNoBody) (met
 				Kind:       "App",
 				Verbs:      []string{"get"},
 			},
+			{
+				Name:       "apps/plan",
+				Namespaced: true,
+				Kind:       "App",
+				Verbs:      []string{"get", "create"},
+			},
 		},
 	}, nil
 }
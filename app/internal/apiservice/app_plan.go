@@ -0,0 +1,71 @@
+package apiservice
+
+import (
+	"context"
+	"library"
+
+	"github.com/go-fuego/fuego"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	appv1 "multi.ch/app/api/v1"
+	"multi.ch/app/internal/controller"
+)
+
+// Plan returns the changes the next reconciliation of the app would perform.
+func (r *AppAgentRepository) Plan(c fuego.ContextNoBody) (*APIResponse[library.Plan], error) {
+	var key = types.NamespacedName{
+		Namespace: c.PathParam("namespace"),
+		Name:      c.PathParam("resource"),
+	}
+
+	var app appv1.App
+	if err := r.cluster.Get(c, key, &app); err != nil {
+		return nil, fuego.NotFoundError{
+			Detail: err.Error(),
+		}
+	}
+
+	return r.plan(c, &app)
+}
+
+// PlanSpec returns the changes a reconciliation would perform if the app had the given spec.
+func (r *AppAgentRepository) PlanSpec(c fuego.ContextWithBody[appv1.App]) (*APIResponse[library.Plan], error) {
+	body, err := c.Body()
+	if err != nil {
+		return nil, fuego.BadRequestError{
+			Detail: err.Error(),
+		}
+	}
+
+	var key = types.NamespacedName{
+		Namespace: c.PathParam("namespace"),
+		Name:      c.PathParam("resource"),
+	}
+
+	// Only the spec is proposed, the rest of the app is the live one
+	var app appv1.App
+	err = r.cluster.Get(c, key, &app)
+	if apierrors.IsNotFound(err) {
+		app = appv1.App{}
+		app.Name = key.Name
+		app.Namespace = key.Namespace
+	} else if err != nil {
+		return nil, fuego.InternalServerError{
+			Detail: err.Error(),
+		}
+	}
+	app.Spec = body.Spec
+
+	return r.plan(c, &app)
+}
+
+func (r *AppAgentRepository) plan(ctx context.Context, app *appv1.App) (*APIResponse[library.Plan], error) {
+	plan, err := controller.Plan(ctx, r.cluster, app)
+	if err != nil {
+		return nil, fuego.InternalServerError{
+			Detail: err.Error(),
+		}
+	}
+
+	return NewAPIResponse(*plan), nil
+}
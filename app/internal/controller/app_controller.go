@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -25,6 +26,8 @@ import (
 
 const (
 	Selector = "app.multi.ch/app-name"
+	// FieldManager owns the fields the operator applies on the children
+	FieldManager = "app-operator"
 )
 
 // AppReconciler reconciles a App object
@@ -36,9 +39,12 @@ type AppReconciler struct {
 	RuntimeScheme *runtime.Scheme
 	controller    controller.TypedController[reconcile.Request]
 
-	app appv1.App
+	// MaxConcurrentReconciles is the number of workers of the controller
+	MaxConcurrentReconciles int
+}
 
-	// Children
+// appState holds the children of a reconciliation
+type appState struct {
 	configMap  corev1.ConfigMap
 	deployment appsv1.Deployment
 	service    corev1.Service
@@ -54,30 +60,43 @@ func (reconciler *AppReconciler) GetFinalizer() string {
 	return "app.multi.ch/finalizer"
 }
 
-func (reconciler *AppReconciler) GetCustomResource() *appv1.App {
-	return &reconciler.app
+func (reconciler *AppReconciler) GetCustomResource(ctx context.Context) *appv1.App {
+	return library.GetCustomResource[*appv1.App](ctx)
+}
+
+func (reconciler *AppReconciler) SetCustomResource(ctx context.Context, app *appv1.App) {
+	library.SetCustomResource(ctx, app)
 }
 
-func (reconciler *AppReconciler) SetCustomResource(app *appv1.App) {
-	reconciler.app = *app
+func (reconciler *AppReconciler) state(ctx context.Context) *appState {
+	return library.GetStateValue[appState](ctx)
 }
 
 func (reconciler *AppReconciler) GetChildren(ctx context.Context, req ctrl.Request) ([]library.GenericChildResource, error) {
+	state := reconciler.state(ctx)
+
 	return []library.GenericChildResource{
 		library.NewChildResource(
 			&corev1.ConfigMap{},
-			library.WithChildOutput(&reconciler.configMap),
+			library.WithChildOutput(&state.configMap),
 			library.WithChildGenerator(reconciler.configMapGenerator),
+			library.WithServerSideApply[*corev1.ConfigMap](FieldManager),
+			library.WithDriftPolicy[*corev1.ConfigMap](library.DriftPolicyRevert),
 		),
 		library.NewChildResource(
 			&appsv1.Deployment{},
-			library.WithChildOutput(&reconciler.deployment),
+			library.WithChildOutput(&state.deployment),
 			library.WithChildGenerator(reconciler.deploymentGenerator),
+			library.WithServerSideApply[*appsv1.Deployment](FieldManager),
+			library.WithDriftPolicy[*appsv1.Deployment](library.DriftPolicyRevert),
+			library.WithImmutableFields[*appsv1.Deployment]("spec.selector"),
 		),
 		library.NewChildResource(
 			&corev1.Service{},
-			library.WithChildOutput(&reconciler.service),
+			library.WithChildOutput(&state.service),
 			library.WithChildGenerator(reconciler.serviceGenerator),
+			library.WithServerSideApply[*corev1.Service](FieldManager),
+			library.WithDriftPolicy[*corev1.Service](library.DriftPolicyReport),
 		),
 	}, nil
 }
@@ -94,9 +113,15 @@ func (reconciler *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	logger := logf.FromContext(ctx)
 
 	stepper := library.NewStepper(logger,
+		library.WithControllerName("app"),
+		library.WithReconciler(reconciler),
+		library.WithInterceptor(library.RecoverInterceptor()),
 		library.WithStep(library.NewFindControllerResourceStep(reconciler)),
+		library.WithStep(library.NewDeletionProtectionStep(reconciler)),
 		library.WithStep(library.NewReconcileChildrenStep(reconciler)),
-		library.WithStep(reconciler.NewFillContractStep()),
+		library.WithStepOutsideFinalization(reconciler,
+			library.NewStepIf(reconciler.serviceExists, reconciler.NewFillContractStep()),
+		),
 		library.WithStep(library.NewEndStep(reconciler)),
 	)
 
@@ -107,9 +132,15 @@ func (reconciler *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request
 func (reconciler *AppReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	reconciler.Manager = mgr
 
+	// The events of the dependencies are mapped to the controller resources depending on them
+	if err := library.IndexDependencies(context.Background(), mgr.GetFieldIndexer(), &appv1.App{}); err != nil {
+		return err
+	}
+
 	controller, err := ctrl.NewControllerManagedBy(mgr).
-		For(&appv1.App{}).
+		For(&appv1.App{}, builder.WithPredicates(library.ControllerResourcePredicate())).
 		Named("app").
+		WithOptions(controller.Options{MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles}).
 		Build(reconciler)
 	if err != nil {
 		return err
@@ -145,8 +176,10 @@ func (reconciler *AppReconciler) configMapGenerator(ctx context.Context, req ctr
 		return nil, false, err
 	}
 
+	app := reconciler.GetCustomResource(ctx)
+
 	workloadConfigurationData := WorkloadConfigurationTemplateData{
-		Command: reconciler.app.Spec.Command,
+		Command: app.Spec.Command,
 	}
 
 	var output bytes.Buffer
@@ -157,7 +190,7 @@ func (reconciler *AppReconciler) configMapGenerator(ctx context.Context, req ctr
 
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      reconciler.app.Name,
+			Name:      app.Name,
 			Namespace: req.Namespace,
 		},
 		Data: map[string]string{
@@ -169,14 +202,16 @@ func (reconciler *AppReconciler) configMapGenerator(ctx context.Context, req ctr
 }
 
 func (reconciler *AppReconciler) serviceGenerator(ctx context.Context, req ctrl.Request) (*corev1.Service, bool, error) {
+	app := reconciler.GetCustomResource(ctx)
+
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      reconciler.app.Name,
+			Name:      app.Name,
 			Namespace: req.Namespace,
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: map[string]string{
-				Selector: reconciler.app.Name,
+				Selector: app.Name,
 			},
 			Ports: []corev1.ServicePort{
 				{
@@ -189,7 +224,7 @@ func (reconciler *AppReconciler) serviceGenerator(ctx context.Context, req ctrl.
 					Name:       "workload",
 					Protocol:   corev1.ProtocolTCP,
 					Port:       80,
-					TargetPort: intstr.FromInt(int(reconciler.app.Spec.Port)),
+					TargetPort: intstr.FromInt(int(app.Spec.Port)),
 				},
 			},
 			Type: corev1.ServiceTypeClusterIP,
@@ -198,21 +233,23 @@ func (reconciler *AppReconciler) serviceGenerator(ctx context.Context, req ctrl.
 }
 
 func (reconciler *AppReconciler) deploymentGenerator(ctx context.Context, req ctrl.Request) (*appsv1.Deployment, bool, error) {
+	app := reconciler.GetCustomResource(ctx)
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      reconciler.app.Name,
+			Name:      app.Name,
 			Namespace: req.Namespace,
 		},
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					Selector: reconciler.app.Name,
+					Selector: app.Name,
 				},
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						Selector: reconciler.app.Name,
+						Selector: app.Name,
 					},
 				},
 				Spec: corev1.PodSpec{
@@ -244,7 +281,7 @@ func (reconciler *AppReconciler) deploymentGenerator(ctx context.Context, req ct
 							VolumeSource: corev1.VolumeSource{
 								ConfigMap: &corev1.ConfigMapVolumeSource{
 									LocalObjectReference: corev1.LocalObjectReference{
-										Name: reconciler.app.Name,
+										Name: app.Name,
 									},
 									DefaultMode: library.Opt(int32(0555)),
 								},
@@ -263,24 +300,32 @@ func (reconciler *AppReconciler) deploymentGenerator(ctx context.Context, req ct
 	}, false, nil
 }
 
+func (reconciler *AppReconciler) serviceExists(ctx context.Context, req ctrl.Request) bool {
+	return reconciler.state(ctx).service.UID != ""
+}
+
 func (reconciler *AppReconciler) NewFillContractStep() library.Step {
 	return library.Step{
 		Name: "Fill Contract",
 		Step: func(ctx context.Context, req ctrl.Request) library.StepResult {
+			app := reconciler.GetCustomResource(ctx)
+
 			newContract := routev1.RouteContract{
 				ServiceRef: &routev1.RouteContractLocalServiceRef{
-					Name: reconciler.service.Name,
+					Name: reconciler.state(ctx).service.Name,
 					Port: 80,
 				},
 			}
 
-			if reflect.DeepEqual(reconciler.app.Status.RouteContractInjector.RouteContract, newContract) {
-				return library.ResultSuccess()
-			}
-
-			reconciler.app.Status.RouteContractInjector.RouteContract = newContract
+			err := library.UpdateStatus(ctx, reconciler, func(status *library.Status) bool {
+				if reflect.DeepEqual(app.Status.RouteContractInjector.RouteContract, newContract) {
+					return false
+				}
 
-			if err := reconciler.Status().Update(ctx, &reconciler.app); err != nil {
+				app.Status.RouteContractInjector.RouteContract = newContract
+				return true
+			})
+			if err != nil {
 				return library.ResultInError(err)
 			}
 
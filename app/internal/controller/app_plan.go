@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"context"
+	"library"
+
+	appv1 "multi.ch/app/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Plan computes the changes a reconciliation of the app would perform on its
+// children, without writing anything. The app may carry a spec that is not applied yet.
+func Plan(ctx context.Context, cluster client.Client, app *appv1.App) (*library.Plan, error) {
+	return library.NewPlan(ctx, newPlanReconciler(cluster), app)
+}
+
+// newPlanReconciler returns a reconciler reading with the client of the plan, as
+// there is no manager.
+func newPlanReconciler(cluster client.Client) *AppReconciler {
+	return &AppReconciler{
+		Manager: library.NewPlanManager(cluster),
+		Client:  cluster,
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appv1 "multi.ch/app/api/v1"
+)
+
+func TestPlan(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &appv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+		Spec:       appv1.AppSpec{Port: 8080, Command: "sleep 10"},
+	}
+	cluster := fake.NewClientBuilder().WithScheme(scheme).WithObjects(app).Build()
+
+	plan, err := Plan(context.Background(), cluster, app.DeepCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kinds := map[string]bool{}
+	for _, change := range plan.Changes {
+		if change.Action != library.PlanActionCreate {
+			t.Errorf("expected the children to be created, got %+v", change)
+		}
+		kinds[change.Kind] = true
+	}
+	for _, kind := range []string{"ConfigMap", "Deployment", "Service"} {
+		if !kinds[kind] {
+			t.Errorf("expected the %s to be planned, got %+v", kind, plan.Changes)
+		}
+	}
+
+	// The steps reading through the manager work without one
+	reconciler := newPlanReconciler(cluster)
+	reconciler.GetEventRecorderFor(library.EventSource).Event(app, corev1.EventTypeWarning, "Test", "dropped")
+	if err := reconciler.GetAPIReader().Get(context.Background(), client.ObjectKeyFromObject(app), &appv1.App{}); err != nil {
+		t.Errorf("expected the API reader to read with the client, got %v", err)
+	}
+	if err := reconciler.GetCache().Get(context.Background(), client.ObjectKeyFromObject(app), &appv1.App{}); err != nil {
+		t.Errorf("expected the cache to read with the client, got %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package library
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Backoff configures the delays returned by ResultRequeueWithBackoff.
+type Backoff struct {
+	// Base is the delay after the first failure, it doubles after every consecutive failure.
+	// DefaultBackoff.Base is used when it is not set
+	Base time.Duration
+	// Max caps the delay, DefaultBackoff.Max is used when it is not set
+	Max time.Duration
+	// Jitter adds up to this fraction of the delay at random, e.g. 0.1 for 10%
+	Jitter float64
+}
+
+var DefaultBackoff = Backoff{
+	Base:   5 * time.Second,
+	Max:    5 * time.Minute,
+	Jitter: 0.1,
+}
+
+// WithBackoff sets the backoff used by ResultRequeueWithBackoff in the steps of the Stepper.
+func WithBackoff(base, maxDelay time.Duration, jitter float64) StepperOptions {
+	return func(s *Stepper) {
+		s.backoff = Backoff{
+			Base:   base,
+			Max:    maxDelay,
+			Jitter: jitter,
+		}
+	}
+}
+
+// Delay returns the delay to wait after the given number of consecutive failures.
+func (backoff Backoff) Delay(failures int) time.Duration {
+	maxDelay := backoff.Max
+	if maxDelay <= 0 {
+		maxDelay = DefaultBackoff.Max
+	}
+
+	delay := backoff.Base
+	if delay <= 0 {
+		delay = DefaultBackoff.Base
+	}
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if backoff.Jitter > 0 {
+		delay += time.Duration(float64(delay) * backoff.Jitter * rand.Float64())
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+type failureKey struct {
+	controller string
+	object     string
+	step       string
+	target     string
+}
+
+// failureTracker counts the consecutive failures per controller, object, step and
+// target of the step. Steppers are created for every reconciliation, so the counts are kept globally.
+type failureTracker struct {
+	lock     sync.Mutex
+	failures map[failureKey]int
+}
+
+var failures = &failureTracker{
+	failures: make(map[failureKey]int),
+}
+
+func (tracker *failureTracker) increment(key failureKey) int {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.failures[key]++
+	return tracker.failures[key]
+}
+
+func (tracker *failureTracker) reset(key failureKey) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	delete(tracker.failures, key)
+}
+
+// forget drops the counts of every step of the object.
+func (tracker *failureTracker) forget(controller, object string) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	for key := range tracker.failures {
+		if key.controller == controller && key.object == object {
+			delete(tracker.failures, key)
+		}
+	}
+}
+
+type stepNameContextKey struct{}
+
+// backoffContextKey carries the backoff of the step group being executed.
+type backoffContextKey struct{}
+
+type failureTargetContextKey struct{}
+
+// withFailureTarget counts the failures of the step apart for the object it
+// handles, as the steps of the same kind run concurrently.
+func withFailureTarget(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) context.Context {
+	return context.WithValue(ctx, failureTargetContextKey{}, gvk.String()+" "+key.String())
+}
+
+func newFailureKey(ctx context.Context, req ctrl.Request) failureKey {
+	key := failureKey{
+		object: req.String(),
+	}
+	if stepper, ok := ctx.Value(stepperContextKey{}).(*Stepper); ok {
+		key.controller = stepper.name
+	}
+	if step, ok := ctx.Value(stepNameContextKey{}).(string); ok {
+		key.step = step
+	}
+	if target, ok := ctx.Value(failureTargetContextKey{}).(string); ok {
+		key.target = target
+	}
+	return key
+}
+
+// ResultRequeueWithBackoff requeues the request after a delay growing with the
+// number of consecutive failures of the current step for this object.
+// The count is reset as soon as the step succeeds.
+func ResultRequeueWithBackoff(ctx context.Context, req ctrl.Request) StepResult {
+	backoff := DefaultBackoff
+	if groupBackoff, ok := ctx.Value(backoffContextKey{}).(Backoff); ok {
+		backoff = groupBackoff
+	} else if stepper, ok := ctx.Value(stepperContextKey{}).(*Stepper); ok {
+		backoff = stepper.backoff
+	}
+
+	count := failures.increment(newFailureKey(ctx, req))
+
+	return StepResult{
+		requeueAfter: backoff.Delay(count),
+		failures:     count,
+	}
+}
+
+// forgetFailures drops the counts of the object, once it no longer exists.
+func forgetFailures(ctx context.Context, req ctrl.Request) {
+	key := newFailureKey(ctx, req)
+	failures.forget(key.controller, key.object)
+}
+
+// resetFailures resets the count of the current step when it succeeded.
+func resetFailures(ctx context.Context, req ctrl.Request, result StepResult) {
+	if result.ShouldReturn() {
+		return
+	}
+
+	failures.reset(newFailureKey(ctx, req))
+}
@@ -0,0 +1,67 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := library.Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, delay := range expected {
+		if actual := backoff.Delay(i + 1); actual != delay {
+			t.Errorf("expected a delay of %s after %d failures, got %s", delay, i+1, actual)
+		}
+	}
+
+	backoff.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		if delay := backoff.Delay(1); delay < time.Second || delay > 1500*time.Millisecond {
+			t.Fatalf("expected the jittered delay to be between 1s and 1.5s, got %s", delay)
+		}
+	}
+}
+
+func TestResultRequeueWithBackoff(t *testing.T) {
+	failing := true
+	newStepper := func() *library.Stepper {
+		return library.NewStepper(logr.Discard(),
+			library.WithControllerName("test-backoff"),
+			library.WithBackoff(time.Second, time.Minute, 0),
+			library.WithStep(library.NewStep("Wait", func(ctx context.Context, req ctrl.Request) library.StepResult {
+				if failing {
+					return library.ResultRequeueWithBackoff(ctx, req)
+				}
+				return library.ResultSuccess()
+			})),
+		)
+	}
+	execute := func() reconcile.Result {
+		result, err := newStepper().Execute(context.Background(), ctrl.Request{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if result := execute(); result.RequeueAfter != expected {
+			t.Fatalf("expected a requeue after %s, got %s", expected, result.RequeueAfter)
+		}
+	}
+
+	failing = false
+	execute()
+
+	failing = true
+	if result := execute(); result.RequeueAfter != time.Second {
+		t.Errorf("the failure count should be reset on success, got a requeue after %s", result.RequeueAfter)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -15,14 +17,26 @@ type GenericChildResource interface {
 	Get() client.Object
 	Status(obj client.Object) *Status
 	Kind() string
+	FieldManager() string
+	DriftPolicy() DriftPolicy
+	DeletionPolicy() DeletionPolicy
+	ImmutableFields() []string
+	RecreateOnImmutableError() bool
 }
 
 var _ GenericChildResource = &ChildResource[client.Object]{}
+var _ GenericChildResource = &UntypedChildResource{}
 
 type ChildResource[T client.Object] struct {
-	generatorF   ChildGenerator[T]
-	statusGetter func(T) *Status
-	output       T
+	generatorF      ChildGenerator[T]
+	statusGetter    func(T) *Status
+	output          T
+	fieldManager    string
+	driftPolicy     DriftPolicy
+	deletionPolicy  DeletionPolicy
+	immutableFields []string
+
+	recreateOnImmutableError bool
 }
 
 type ChildResourceOption[T client.Object] func(*ChildResource[T])
@@ -45,6 +59,48 @@ func WithChildOutput[T client.Object](obj T) ChildResourceOption[T] {
 	}
 }
 
+// WithServerSideApply writes the child with server-side apply under the field
+// manager instead of updating it as a whole. Only the fields set by the generator
+// are owned, the fields set by other controllers are left untouched.
+func WithServerSideApply[T client.Object](fieldManager string) ChildResourceOption[T] {
+	return func(c *ChildResource[T]) {
+		c.fieldManager = fieldManager
+	}
+}
+
+// WithDriftPolicy sets what to do when the live child differs from the generated
+// one, drift is ignored by default.
+func WithDriftPolicy[T client.Object](policy DriftPolicy) ChildResourceOption[T] {
+	return func(c *ChildResource[T]) {
+		c.driftPolicy = policy
+	}
+}
+
+// WithDeletionPolicy sets what happens to the child when its parent is deleted
+// or when it is not generated anymore, the child is deleted by default.
+func WithDeletionPolicy[T client.Object](policy DeletionPolicy) ChildResourceOption[T] {
+	return func(c *ChildResource[T]) {
+		c.deletionPolicy = policy
+	}
+}
+
+// WithImmutableFields declares the paths of the fields that cannot be updated,
+// e.g. "spec.selector". The child is deleted and created again when they change.
+func WithImmutableFields[T client.Object](paths ...string) ChildResourceOption[T] {
+	return func(c *ChildResource[T]) {
+		c.immutableFields = append(c.immutableFields, paths...)
+	}
+}
+
+// WithRecreateOnImmutableError recreates the child when the API server rejects
+// an update because a field is immutable, for the fields not declared with
+// WithImmutableFields.
+func WithRecreateOnImmutableError[T client.Object](recreate bool) ChildResourceOption[T] {
+	return func(c *ChildResource[T]) {
+		c.recreateOnImmutableError = recreate
+	}
+}
+
 func DefaultStatusGetter[T client.Object](obj T) *Status {
 	return &Status{
 		Conditions: []metav1.Condition{
@@ -56,9 +112,13 @@ func DefaultStatusGetter[T client.Object](obj T) *Status {
 	}
 }
 
-func NewChildResource[T client.Object](_ T, opts ...ChildResourceOption[T]) *ChildResource[T] {
+// NewChildResource creates a child of the type of the sample. Its readiness is
+// reported by the getter registered for its type, see RegisterReadinessGetter.
+func NewChildResource[T client.Object](sample T, opts ...ChildResourceOption[T]) *ChildResource[T] {
 	c := &ChildResource[T]{
-		statusGetter: DefaultStatusGetter[T],
+		statusGetter:   ReadinessGetter(sample),
+		driftPolicy:    DriftPolicyIgnore,
+		deletionPolicy: DeletionPolicyDelete,
 	}
 
 	for _, opt := range opts {
@@ -72,6 +132,26 @@ func (c *ChildResource[T]) Kind() string {
 	return reflect.TypeOf(c.output).Elem().Name()
 }
 
+func (c *ChildResource[T]) FieldManager() string {
+	return c.fieldManager
+}
+
+func (c *ChildResource[T]) DriftPolicy() DriftPolicy {
+	return c.driftPolicy
+}
+
+func (c *ChildResource[T]) DeletionPolicy() DeletionPolicy {
+	return c.deletionPolicy
+}
+
+func (c *ChildResource[T]) ImmutableFields() []string {
+	return c.immutableFields
+}
+
+func (c *ChildResource[T]) RecreateOnImmutableError() bool {
+	return c.recreateOnImmutableError
+}
+
 func (c *ChildResource[T]) Generator(ctx context.Context, req ctrl.Request) (obj client.Object, skip bool, err error) {
 	return c.generatorF(ctx, req)
 }
@@ -93,3 +173,31 @@ func (c *ChildResource[T]) Get() client.Object {
 func (c *ChildResource[T]) Status(obj client.Object) *Status {
 	return c.statusGetter(obj.(T))
 }
+
+// UntypedChildResource is a child of a kind that is not compiled in the operator,
+// e.g. a CRD of another project. Its generator returns unstructured objects.
+type UntypedChildResource struct {
+	*ChildResource[*unstructured.Unstructured]
+	gvk schema.GroupVersionKind
+}
+
+func NewUntypedChildResource(gvk schema.GroupVersionKind, opts ...ChildResourceOption[*unstructured.Unstructured]) *UntypedChildResource {
+	return &UntypedChildResource{
+		ChildResource: NewChildResource(&unstructured.Unstructured{}, opts...),
+		gvk:           gvk,
+	}
+}
+
+func (c *UntypedChildResource) Kind() string {
+	return c.gvk.Kind
+}
+
+// Generator sets the GVK of the generated object.
+func (c *UntypedChildResource) Generator(ctx context.Context, req ctrl.Request) (obj client.Object, skip bool, err error) {
+	generated, skip, err := c.generatorF(ctx, req)
+	if generated != nil {
+		generated.SetGroupVersionKind(c.gvk)
+	}
+
+	return generated, skip, err
+}
@@ -0,0 +1,102 @@
+package library
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DeletionPolicy tells what happens to a child when its parent is deleted or
+// when it is not generated anymore.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the child
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan removes the owner reference of the parent and leaves the child behind
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+	// DeletionPolicyRetain leaves the child untouched while the parent exists and
+	// orphans it when the parent is deleted
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// releaseChild deletes, orphans or retains the child according to the policy
+// recorded on its reference, then forgets it in the status of the parent.
+// A retained child stays recorded until the parent is deleted, so that it can be
+// orphaned then. The object is nil when the child does not exist anymore.
+func releaseChild[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	ref *ObjectReference,
+	object client.Object,
+) error {
+	orphaned := false
+
+	if object != nil {
+		switch ref.DeletionPolicy {
+		case DeletionPolicyRetain, DeletionPolicyOrphan:
+			if ref.DeletionPolicy == DeletionPolicyRetain && !isFinalizing(ctx, reconciler) {
+				return nil
+			}
+			if err := orphanChild(ctx, reconciler, object); err != nil {
+				return err
+			}
+			orphaned = true
+		default:
+			if err := reconciler.Delete(ctx, object); client.IgnoreNotFound(err) != nil {
+				return errors.Wrap(err, "failed to delete child resource")
+			}
+		}
+	}
+
+	return UpdateStatus(ctx, reconciler, func(status *Status) bool {
+		changed := status.ChildResources.Remove(ref)
+		if orphaned {
+			orphan := *ref
+			orphan.Status = ""
+			orphan.Reason = ""
+			orphan.Message = ""
+			orphan.DriftedFields = nil
+			changed = status.OrphanedResources.Set(&orphan) || changed
+		}
+		return setDriftedCondition(status) || changed
+	})
+}
+
+// orphanChild removes the owner reference of the parent from the child.
+func orphanChild[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	object client.Object,
+) error {
+	original, ok := object.DeepCopyObject().(client.Object)
+	if !ok {
+		return errors.New("failed to copy child resource")
+	}
+
+	controller := reconciler.GetCustomResource(ctx)
+
+	var owners []metav1.OwnerReference
+	for _, owner := range object.GetOwnerReferences() {
+		if owner.UID != controller.GetUID() {
+			owners = append(owners, owner)
+		}
+	}
+	if len(owners) == len(object.GetOwnerReferences()) {
+		return nil
+	}
+	object.SetOwnerReferences(owners)
+
+	if err := reconciler.Patch(ctx, object, client.MergeFrom(original)); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrap(err, "failed to orphan child resource")
+	}
+
+	return nil
+}
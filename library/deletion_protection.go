@@ -0,0 +1,65 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ForceDeleteAnnotation lets a controller resource be finalized while other
+// resources still depend on it, when set to "true".
+const ForceDeleteAnnotation = "multi.ch/force-delete"
+
+// NewDeletionProtectionStep blocks the finalization of the controller resource
+// while it is listed as a dependency in the managed-by annotation, the dependents
+// are reported in the Ready condition. The dependents remove the annotation when
+// they stop depending on the controller resource, which reconciles it again.
+// The step must run before the children are released.
+func NewDeletionProtectionStep[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+) Step {
+	return Step{
+		Name: StepDeletionProtection,
+		Step: func(ctx context.Context, req ctrl.Request) StepResult {
+			controller := reconciler.GetCustomResource(ctx)
+			if !isFinalizing(ctx, reconciler) || GetAnnotation(controller, ForceDeleteAnnotation) == "true" {
+				return ResultSuccess()
+			}
+
+			dependents, err := GetManagedBy(controller)
+			if err != nil {
+				return ResultInError(errors.Wrap(err, "failed to read the dependents"))
+			}
+			if len(dependents) == 0 {
+				return ResultSuccess()
+			}
+
+			names := make([]string, 0, len(dependents))
+			for _, dependent := range dependents {
+				names = append(names, fmt.Sprintf("%s %s/%s", dependent.GVK.Kind, dependent.Namespace, dependent.Name))
+			}
+
+			err = UpdateStatus(ctx, reconciler, func(status *Status) bool {
+				return meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+					Type:               ConditionTypeReady,
+					Status:             metav1.ConditionFalse,
+					Reason:             ReasonDeletionBlocked,
+					Message:            fmt.Sprintf("the resource is still used by %s, set the %s annotation to \"true\" to delete it anyway", strings.Join(names, ", "), ForceDeleteAnnotation),
+					ObservedGeneration: controller.GetGeneration(),
+				})
+			})
+			if err != nil {
+				return ResultInError(err)
+			}
+
+			return ResultEarlyReturn()
+		},
+	}
+}
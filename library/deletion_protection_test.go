@@ -0,0 +1,64 @@
+package library_test
+
+import (
+	"context"
+	"encoding/json"
+	"library"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDeletionProtection(t *testing.T) {
+	managedBy, err := json.Marshal([]library.ManagedBy{{
+		Name:      "route",
+		Namespace: "default",
+		GVK:       schema.GroupVersionKind{Group: "route.multi.ch", Version: "v1", Kind: "Route"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{
+		Name:              "app",
+		Namespace:         "default",
+		UID:               "app-uid",
+		Finalizers:        []string{"test.multi.ch/finalizer"},
+		DeletionTimestamp: &metav1.Time{Time: metav1.Now().Time},
+		Annotations:       map[string]string{library.AnnotationRef: string(managedBy)},
+	}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	reconciler := newTestReconciler(t, app)
+	execute := func() {
+		if _, err := executeSteps(reconciler, req, library.NewDeletionProtectionStep(reconciler), library.NewEndStep(reconciler)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	execute()
+	ready := meta.FindStatusCondition(reconciler.app.Status.Conditions, library.ConditionTypeReady)
+	if ready == nil || ready.Reason != library.ReasonDeletionBlocked {
+		t.Fatalf("expected the deletion to be blocked, got %+v", ready)
+	}
+
+	var current appv1.App
+	if err := reconciler.Get(context.Background(), req.NamespacedName, &current); err != nil {
+		t.Fatalf("expected the app to be kept, got %v", err)
+	}
+	current.Annotations[library.ForceDeleteAnnotation] = "true"
+	if err := reconciler.Update(context.Background(), &current); err != nil {
+		t.Fatal(err)
+	}
+
+	execute()
+	if err := reconciler.Get(context.Background(), req.NamespacedName, &appv1.App{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the forced deletion to complete, got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ type GenericDependencyResource interface {
 	Status(obj client.Object) *Status
 	ShouldWaitForReady() bool
 	IsOptional() bool
+	IsMetadataOnly() bool
 	Kind() string
 }
 
@@ -26,6 +27,7 @@ type DependencyResource[T client.Object] struct {
 	statusGetter func(T) *Status
 	output       T
 	isOptional   bool
+	metadataOnly bool
 	waitForReady bool
 	name         string
 	namespace    string
@@ -51,6 +53,15 @@ func WithOptional[T client.Object](optional bool) DependencyResourceOption[T] {
 	}
 }
 
+// WithMetadataOnly makes the dependency watched with its metadata only, it is read
+// from the API server instead of the cache. The readiness getter still sees the
+// whole object.
+func WithMetadataOnly[T client.Object](metadataOnly bool) DependencyResourceOption[T] {
+	return func(c *DependencyResource[T]) {
+		c.metadataOnly = metadataOnly
+	}
+}
+
 func WithName[T client.Object](name string) DependencyResourceOption[T] {
 	return func(c *DependencyResource[T]) {
 		c.name = name
@@ -69,9 +80,12 @@ func WithWaitForReady[T client.Object](waitForReady bool) DependencyResourceOpti
 	}
 }
 
-func NewDependencyResource[T client.Object](_ T, opts ...DependencyResourceOption[T]) *DependencyResource[T] {
+// NewDependencyResource creates a dependency of the type of the sample. When it
+// waits for the dependency, its readiness is reported by the getter registered
+// for its type, see RegisterReadinessGetter. Untyped dependencies use UnstructuredReadiness.
+func NewDependencyResource[T client.Object](sample T, opts ...DependencyResourceOption[T]) *DependencyResource[T] {
 	c := &DependencyResource[T]{
-		statusGetter: DefaultStatusGetter[T],
+		statusGetter: ReadinessGetter(sample),
 	}
 
 	for _, opt := range opts {
@@ -111,6 +125,10 @@ func (c *DependencyResource[T]) IsOptional() bool {
 	return c.isOptional
 }
 
+func (c *DependencyResource[T]) IsMetadataOnly() bool {
+	return c.metadataOnly
+}
+
 func (c *DependencyResource[T]) Key() types.NamespacedName {
 	return types.NamespacedName{
 		Name:      c.name,
@@ -136,6 +154,10 @@ func NewUntypedDependencyResource(gvk schema.GroupVersionKind, opts ...Dependenc
 	return c
 }
 
+func (c *UntypedDependencyResource) Kind() string {
+	return c.gvk.Kind
+}
+
 func (c *UntypedDependencyResource) New() client.Object {
 	obj := NewInstanceOf(c.output)
 	obj.SetAPIVersion(c.gvk.GroupVersion().String())
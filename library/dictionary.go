@@ -1,15 +1,25 @@
 package library
 
 const (
-	ConditionTypeReady = "Ready"
+	ConditionTypeReady   = "Ready"
+	ConditionTypeDrifted = "Drifted"
 )
 
 const (
-	ReasonReconciling = "Reconciling"
-	ReasonReconciled  = "Reconciled"
-	ReasonFinalizing  = "Finalizing"
-	ReasonUnknown     = "Unknown"
-	ReasonNotFound    = "NotFound"
+	ReasonReconciling     = "Reconciling"
+	ReasonReconciled      = "Reconciled"
+	ReasonFinalizing      = "Finalizing"
+	ReasonUnknown         = "Unknown"
+	ReasonNotFound        = "NotFound"
+	ReasonChildDrifted    = "ChildDrifted"
+	ReasonProgressing     = "Progressing"
+	ReasonPending         = "Pending"
+	ReasonNotAccepted     = "NotAccepted"
+	ReasonTerminating     = "Terminating"
+	ReasonAbsent          = "Absent"
+	ReasonDeletionBlocked = "DeletionBlocked"
+	ReasonLost            = "Lost"
+	ReasonReplaced        = "Replaced"
 )
 
 const (
@@ -19,4 +29,5 @@ const (
 	StepReconcileChild         = "ReconcileChild%s"
 	StepReconcileChildren      = "ReconcileChildren"
 	StepEndReconciliation      = "EndReconciliation"
+	StepDeletionProtection     = "DeletionProtection"
 )
@@ -0,0 +1,60 @@
+package library
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DriftPolicy tells what to do when the live child differs from the generated
+// one while its hash annotation is unchanged, e.g. after a `kubectl edit`.
+type DriftPolicy string
+
+const (
+	// DriftPolicyIgnore does not compare the live child with the generated one
+	DriftPolicyIgnore DriftPolicy = "Ignore"
+	// DriftPolicyReport records the drifted fields and sets the Drifted condition of the parent
+	DriftPolicyReport DriftPolicy = "Report"
+	// DriftPolicyRevert writes the generated child again
+	DriftPolicyRevert DriftPolicy = "Revert"
+)
+
+// detectDrift returns the paths of the generated fields that differ in the live child.
+func detectDrift(actual, desired client.Object) ([]string, error) {
+	diffs, err := diffObjects(actual, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifted []string
+	for _, diff := range diffs {
+		drifted = append(drifted, diff.Path)
+	}
+
+	return drifted, nil
+}
+
+// setDriftedCondition sets the Drifted condition from the drifted fields recorded
+// on the children, the condition is removed when no child drifted.
+func setDriftedCondition(status *Status) bool {
+	var drifted []string
+	for _, child := range status.ChildResources {
+		if len(child.DriftedFields) > 0 {
+			drifted = append(drifted, fmt.Sprintf("%s %s: %s", child.Kind, child.Name, strings.Join(child.DriftedFields, ", ")))
+		}
+	}
+
+	if len(drifted) == 0 {
+		return meta.RemoveStatusCondition(&status.Conditions, ConditionTypeDrifted)
+	}
+
+	return meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    ConditionTypeDrifted,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonChildDrifted,
+		Message: strings.Join(drifted, "; "),
+	})
+}
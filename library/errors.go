@@ -0,0 +1,113 @@
+package library
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ErrorCategory classifies why a step failed. It is used, with the name of the
+// failing step, as the reason of the Ready condition.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryInvalidSpec means that the spec of the resource must change
+	ErrorCategoryInvalidSpec ErrorCategory = "InvalidSpec"
+	// ErrorCategoryConflict means that another actor changed the resource concurrently
+	ErrorCategoryConflict ErrorCategory = "Conflict"
+	// ErrorCategoryDependencyMissing means that a dependency does not exist yet
+	ErrorCategoryDependencyMissing ErrorCategory = "DependencyMissing"
+	// ErrorCategoryTransient means that the API or the environment failed, retrying may succeed
+	ErrorCategoryTransient ErrorCategory = "Transient"
+)
+
+type categorizedError struct {
+	category ErrorCategory
+	err      error
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.err
+}
+
+// NewCategorizedError attaches the category to the error.
+func NewCategorizedError(category ErrorCategory, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &categorizedError{
+		category: category,
+		err:      err,
+	}
+}
+
+// NewTerminalError attaches the category to the error and marks it as terminal,
+// the request is not retried until the resource changes.
+func NewTerminalError(category ErrorCategory, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return reconcile.TerminalError(NewCategorizedError(category, err))
+}
+
+// IsTerminal returns whether the error stops the retries of the request.
+func IsTerminal(err error) bool {
+	return err != nil && errors.Is(err, reconcile.TerminalError(nil))
+}
+
+// ErrorCategoryOf returns the category attached to the error. Uncategorized
+// errors are classified from the API status they carry, terminal errors are
+// considered invalid specs and any other error is considered transient.
+func ErrorCategoryOf(err error) ErrorCategory {
+	var categorized *categorizedError
+	if errors.As(err, &categorized) {
+		return categorized.category
+	}
+
+	switch {
+	case apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err):
+		return ErrorCategoryConflict
+	case apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || IsTerminal(err):
+		return ErrorCategoryInvalidSpec
+	default:
+		return ErrorCategoryTransient
+	}
+}
+
+// conditionReason builds a condition reason in CamelCase from the parts,
+// dropping the characters not allowed in a reason.
+func conditionReason(parts ...string) string {
+	var reason strings.Builder
+	for _, part := range parts {
+		upper := true
+		for _, r := range part {
+			if r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r)) {
+				upper = true
+				continue
+			}
+			if reason.Len() == 0 && !unicode.IsLetter(r) {
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			reason.WriteRune(r)
+		}
+	}
+
+	if reason.Len() == 0 {
+		return ReasonUnknown
+	}
+
+	return reason.String()
+}
@@ -0,0 +1,84 @@
+package library_test
+
+import (
+	"context"
+	"errors"
+	"library"
+	"testing"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestErrorCategoryOf(t *testing.T) {
+	resource := schema.GroupResource{Group: "app.multi.ch", Resource: "apps"}
+
+	cases := map[library.ErrorCategory]error{
+		library.ErrorCategoryDependencyMissing: library.NewCategorizedError(library.ErrorCategoryDependencyMissing, errors.New("missing")),
+		library.ErrorCategoryConflict:          apierrors.NewConflict(resource, "app", errors.New("conflict")),
+		library.ErrorCategoryInvalidSpec:       reconcile.TerminalError(errors.New("invalid")),
+		library.ErrorCategoryTransient:         errors.New("timeout"),
+	}
+
+	for expected, err := range cases {
+		if category := library.ErrorCategoryOf(err); category != expected {
+			t.Errorf("expected %q to be categorized as %s, got %s", err, expected, category)
+		}
+	}
+}
+
+func TestResultTerminal(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	reconciler := newTestReconciler(t, app)
+
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithReconciler(reconciler),
+		library.WithStep(library.NewFindControllerResourceStep(reconciler)),
+		library.WithStep(library.NewStep("Validate spec", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			return library.ResultTerminal(errors.New("the image is empty"))
+		})),
+	)
+
+	_, err := stepper.Execute(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)})
+	if !library.IsTerminal(err) {
+		t.Fatalf("expected a terminal error, got %v", err)
+	}
+
+	condition := meta.FindStatusCondition(reconciler.app.Status.Conditions, library.ConditionTypeReady)
+	if condition == nil || condition.Reason != "ValidateSpecInvalidSpec" {
+		t.Fatalf("expected the Ready reason to be ValidateSpecInvalidSpec, got %+v", condition)
+	}
+}
+
+func TestDependencyMissingReason(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default", UID: "route-uid"}}
+	reconciler := &dependenciesReconciler{testReconciler: newTestReconciler(t, app)}
+	reconciler.dependencies = func(ctx context.Context, req ctrl.Request) []library.GenericDependencyResource {
+		return []library.GenericDependencyResource{
+			library.NewUntypedDependencyResource(
+				appv1.GroupVersion.WithKind("App"),
+				library.WithName[*unstructured.Unstructured]("backend"),
+				library.WithNamespace[*unstructured.Unstructured](req.Namespace),
+			),
+		}
+	}
+
+	_, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewResolveDynamicDependenciesStep(reconciler))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The untyped dependencies are named after their kind
+	condition := meta.FindStatusCondition(reconciler.app.Status.Conditions, library.ConditionTypeReady)
+	if condition == nil || condition.Reason != "ResolveDependencyAppDependencyMissing" {
+		t.Fatalf("expected the Ready reason to be ResolveDependencyAppDependencyMissing, got %+v", condition)
+	}
+}
@@ -0,0 +1,29 @@
+package library
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// EventSource is the component of the events the library records on the controller resources.
+const EventSource = "multi.ch"
+
+const (
+	EventReasonDependencyLost     = "DependencyLost"
+	EventReasonDependencyReplaced = "DependencyReplaced"
+)
+
+// recordWarning records a warning event on the controller resource.
+func recordWarning[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	reason string,
+	format string,
+	args ...any,
+) {
+	reconciler.GetEventRecorderFor(EventSource).Event(reconciler.GetCustomResource(ctx), corev1.EventTypeWarning, reason, fmt.Sprintf(format, args...))
+}
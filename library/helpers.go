@@ -1,6 +1,7 @@
 package library
 
 import (
+	"context"
 	"reflect"
 
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -36,9 +37,10 @@ func NewInstanceOf[ObjectType client.Object](object ObjectType) ObjectType {
 func isFinalizing[
 	ControllerResourceType ControllerResource,
 ](
+	ctx context.Context,
 	reconciler Reconciler[ControllerResourceType],
 ) bool {
-	return reconciler.GetCustomResource().GetDeletionTimestamp() != nil
+	return reconciler.GetCustomResource(ctx).GetDeletionTimestamp() != nil
 }
 
 func SetAnnotation(obj client.Object, key, value string) {
@@ -0,0 +1,119 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// MaxReconcileHistory is the number of reconcile records kept in the status.
+	MaxReconcileHistory = 5
+)
+
+// ReconcileRecord is the outcome of a single execution of the stepper.
+type ReconcileRecord struct {
+	StartTime metav1.Time     `json:"startTime"`
+	Duration  metav1.Duration `json:"duration"`
+	Result    string          `json:"result"`
+
+	// FailedStep is the step that stopped the reconciliation, it is empty when
+	// every step succeeded.
+	// +optional
+	FailedStep string `json:"failedStep,omitempty"`
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+func (record *ReconcileRecord) sameOutcome(other *ReconcileRecord) bool {
+	return record.Result == other.Result &&
+		record.FailedStep == other.FailedStep &&
+		record.Error == other.Error
+}
+
+// RecordReconcile adds the record to the history, dropping the oldest records
+// above MaxReconcileHistory. Consecutive records with the same outcome are not
+// added again so that a stuck resource does not rewrite its status in a loop.
+func (status *Status) RecordReconcile(record ReconcileRecord) bool {
+	if len(status.History) > 0 && status.History[len(status.History)-1].sameOutcome(&record) {
+		return false
+	}
+
+	status.History = append(status.History, record)
+	if len(status.History) > MaxReconcileHistory {
+		status.History = status.History[len(status.History)-MaxReconcileHistory:]
+	}
+
+	return true
+}
+
+// recordExecution records the last step, the progress and the outcome of the
+// execution in the status of the controller resource, then writes the status
+// changes of the execution.
+func (stepper *Stepper) recordExecution(
+	ctx context.Context,
+	req ctrl.Request,
+	batch *statusBatch,
+	startedAt time.Time,
+	lastStep string,
+	completed int,
+	result StepResult,
+) error {
+	if stepper.resource == nil {
+		return nil
+	}
+
+	controller := stepper.resource(ctx)
+	if controller.GetUID() == "" || controller.GetName() != req.Name || controller.GetNamespace() != req.Namespace {
+		// The controller resource was not found during this execution
+		return nil
+	}
+
+	progress := fmt.Sprintf("%d/%d", completed, len(stepper.steps))
+
+	record := ReconcileRecord{
+		StartTime: metav1.NewTime(startedAt),
+		Duration:  metav1.Duration{Duration: time.Since(startedAt).Round(time.Millisecond)},
+		Result:    result.Outcome(),
+	}
+	if result.ShouldReturn() {
+		record.FailedStep = lastStep
+	}
+	if result.err != nil {
+		record.Error = result.err.Error()
+	}
+
+	batch.lock.Lock()
+	batch.apply(controller, func(status *Status) bool {
+		status.LastStep = lastStep
+		status.Progress = progress
+		status.ConsecutiveFailures = result.failures
+		status.RecordReconcile(record)
+
+		if category, err, failed := result.failure(); failed {
+			failedStep := result.step
+			if failedStep == "" {
+				failedStep = lastStep
+			}
+
+			// Report why the reconciliation stopped instead of staying at Reconciling
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               ConditionTypeReady,
+				Status:             metav1.ConditionFalse,
+				Reason:             conditionReason(failedStep, string(category)),
+				Message:            err.Error(),
+				ObservedGeneration: controller.GetGeneration(),
+			})
+		}
+
+		return true
+	})
+	batch.lock.Unlock()
+
+	// The status is only written when it changed
+	return batch.flush(ctx, stepper.client, controller)
+}
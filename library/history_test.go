@@ -0,0 +1,36 @@
+package library_test
+
+import (
+	"fmt"
+	"library"
+	"testing"
+)
+
+func TestRecordReconcile(t *testing.T) {
+	var status library.Status
+
+	if !status.RecordReconcile(library.ReconcileRecord{Result: "success"}) {
+		t.Fatal("the first record should be added")
+	}
+
+	if status.RecordReconcile(library.ReconcileRecord{Result: "success"}) {
+		t.Error("a record with the same outcome should not be added twice in a row")
+	}
+
+	for i := 0; i < library.MaxReconcileHistory+2; i++ {
+		status.RecordReconcile(library.ReconcileRecord{
+			Result:     "error",
+			FailedStep: "ReconcileChildren",
+			Error:      fmt.Sprintf("error %d", i),
+		})
+	}
+
+	if len(status.History) != library.MaxReconcileHistory {
+		t.Fatalf("expected %d records, got %d", library.MaxReconcileHistory, len(status.History))
+	}
+
+	last := status.History[len(status.History)-1]
+	if last.Error != fmt.Sprintf("error %d", library.MaxReconcileHistory+1) {
+		t.Errorf("the last record should be the most recent one, got %q", last.Error)
+	}
+}
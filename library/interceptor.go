@@ -0,0 +1,82 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// StepFunc is the function executed by a step.
+type StepFunc func(ctx context.Context, req ctrl.Request) StepResult
+
+// StepInterceptor wraps the execution of every step of a Stepper, sub-steps included.
+// It must call next to execute the step, and may change its context or its result.
+type StepInterceptor func(ctx context.Context, req ctrl.Request, step Step, next StepFunc) StepResult
+
+// WithInterceptor adds interceptors to the Stepper.
+// The first interceptor added is the outermost one.
+func WithInterceptor(interceptors ...StepInterceptor) StepperOptions {
+	return func(s *Stepper) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// intercept chains the interceptors around the step.
+func intercept(interceptors []StepInterceptor, step Step) StepFunc {
+	next := StepFunc(step.Step)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(ctx context.Context, req ctrl.Request) StepResult {
+			return interceptor(ctx, req, step, inner)
+		}
+	}
+	return next
+}
+
+// RecoverInterceptor turns a panic in a step into an error result.
+func RecoverInterceptor() StepInterceptor {
+	return func(ctx context.Context, req ctrl.Request, step Step, next StepFunc) (result StepResult) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = ResultInError(fmt.Errorf("panic in step %s: %v", step.Name, r))
+			}
+		}()
+
+		return next(ctx, req)
+	}
+}
+
+// LoggingInterceptor logs the outcome and the duration of every step.
+func LoggingInterceptor(logger logr.Logger) StepInterceptor {
+	return func(ctx context.Context, req ctrl.Request, step Step, next StepFunc) StepResult {
+		startedAt := time.Now()
+		result := next(ctx, req)
+
+		values := []any{
+			"step", step.Name,
+			"name", req.Name,
+			"namespace", req.Namespace,
+			"result", result.Outcome(),
+			"duration", time.Since(startedAt),
+		}
+		if result.err != nil {
+			values = append(values, "error", result.err.Error())
+		}
+		logger.Info("Step executed", values...)
+
+		return result
+	}
+}
+
+// TimeoutInterceptor sets a deadline on the context of every step.
+func TimeoutInterceptor(timeout time.Duration) StepInterceptor {
+	return func(ctx context.Context, req ctrl.Request, step Step, next StepFunc) StepResult {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return next(ctx, req)
+	}
+}
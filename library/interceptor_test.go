@@ -0,0 +1,84 @@
+package library_test
+
+import (
+	"context"
+	"errors"
+	"library"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestStepperInterceptors(t *testing.T) {
+	var calls []string
+	trace := func(name string) library.StepInterceptor {
+		return func(ctx context.Context, req ctrl.Request, step library.Step, next library.StepFunc) library.StepResult {
+			calls = append(calls, name+":"+step.Name)
+			return next(ctx, req)
+		}
+	}
+
+	hasDeadline := false
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithInterceptor(trace("outer"), trace("inner")),
+		library.WithInterceptor(library.TimeoutInterceptor(time.Minute)),
+		library.WithStep(library.NewStep("First", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			_, hasDeadline = ctx.Deadline()
+			return library.ResultSuccess()
+		})),
+	)
+
+	if _, err := stepper.Execute(context.Background(), ctrl.Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"outer:First", "inner:First"}
+	if len(calls) != len(expected) || calls[0] != expected[0] || calls[1] != expected[1] {
+		t.Errorf("expected interceptors to be called as %v, got %v", expected, calls)
+	}
+	if !hasDeadline {
+		t.Error("the step context should have a deadline")
+	}
+}
+
+func TestRecoverInterceptor(t *testing.T) {
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithInterceptor(library.RecoverInterceptor()),
+		library.WithStep(library.NewStepGraph("Graph", []library.GraphStep{
+			library.NewGraphStep(library.NewStep("Panicking", func(ctx context.Context, req ctrl.Request) library.StepResult {
+				panic("boom")
+			})),
+		})),
+	)
+
+	if _, err := stepper.Execute(context.Background(), ctrl.Request{}); err == nil {
+		t.Fatal("expected the panic to be turned into an error")
+	}
+}
+
+func TestFaultInjectionInterceptor(t *testing.T) {
+	injected := errors.New("injected")
+	executed := false
+
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithInterceptor(func(ctx context.Context, req ctrl.Request, step library.Step, next library.StepFunc) library.StepResult {
+			if step.Name == "Faulty" {
+				return library.ResultInError(injected)
+			}
+			return next(ctx, req)
+		}),
+		library.WithStep(library.NewStep("Faulty", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			executed = true
+			return library.ResultSuccess()
+		})),
+	)
+
+	if _, err := stepper.Execute(context.Background(), ctrl.Request{}); !errors.Is(err, injected) {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+	if executed {
+		t.Error("the step should not be executed when the interceptor short-circuits it")
+	}
+}
@@ -1,11 +1,12 @@
 package library
 
 import (
-	"fmt"
+	"slices"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 // ObjectReference represents a child resource of a parent resource.
@@ -29,6 +30,18 @@ type ObjectReference struct {
 	Reason string `json:"reason,omitempty"`
 	// +optional
 	Message string `json:"message,omitempty"`
+	// DriftedFields are the generated fields changed out-of-band on the child
+	// +optional
+	DriftedFields []string `json:"driftedFields,omitempty"`
+	// DeletionPolicy tells what happens to the child when it is not needed anymore
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// RecreatedAt is the last time the child was deleted and created again
+	// +optional
+	RecreatedAt *metav1.Time `json:"recreatedAt,omitempty"`
+	// RecreatedFields are the immutable fields that changed on the last recreation
+	// +optional
+	RecreatedFields []string `json:"recreatedFields,omitempty"`
 }
 
 func (obj *ObjectReference) GroupVersionKind() schema.GroupVersionKind {
@@ -43,14 +56,11 @@ func (obj *ObjectReference) GroupVersionKind() schema.GroupVersionKind {
 func NewObjectReference[
 	ControllerResourceType ControllerResource,
 ](reconciler Reconciler[ControllerResourceType], ref client.Object, status metav1.ConditionStatus, generation int64) (*ObjectReference, error) {
-	gvks, _, err := reconciler.Scheme().ObjectKinds(ref)
+	// Unstructured objects carry their own GVK
+	gvk, err := apiutil.GVKForObject(ref, reconciler.Scheme())
 	if err != nil {
 		return nil, err
 	}
-	if len(gvks) == 0 {
-		return nil, fmt.Errorf("no GVK found for object %T", ref)
-	}
-	gvk := gvks[0]
 
 	return &ObjectReference{
 		APIVersion:         gvk.GroupVersion().String(),
@@ -67,14 +77,11 @@ func NewObjectReference[
 func EmptyObjectReference[
 	ControllerResourceType ControllerResource,
 ](reconciler Reconciler[ControllerResourceType], ref client.Object) (*ObjectReference, error) {
-	gvks, _, err := reconciler.Scheme().ObjectKinds(ref)
+	// Unstructured objects carry their own GVK
+	gvk, err := apiutil.GVKForObject(ref, reconciler.Scheme())
 	if err != nil {
 		return nil, err
 	}
-	if len(gvks) == 0 {
-		return nil, fmt.Errorf("no GVK found for object %T", ref)
-	}
-	gvk := gvks[0]
 
 	return &ObjectReference{
 		APIVersion: gvk.GroupVersion().String(),
@@ -111,7 +118,11 @@ func (obj *ObjectReference) Changed(other *ObjectReference) bool {
 		obj.Status != other.Status ||
 		obj.ObservedGeneration != other.ObservedGeneration ||
 		obj.Reason != other.Reason ||
-		obj.Message != other.Message
+		obj.Message != other.Message ||
+		obj.DeletionPolicy != other.DeletionPolicy ||
+		!obj.RecreatedAt.Equal(other.RecreatedAt) ||
+		!slices.Equal(obj.RecreatedFields, other.RecreatedFields) ||
+		!slices.Equal(obj.DriftedFields, other.DriftedFields)
 }
 
 // ObjectReferenceList is a list of ChildResource.
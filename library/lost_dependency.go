@@ -0,0 +1,34 @@
+package library
+
+import (
+	"context"
+)
+
+// previousDependencyRef returns a copy of the reference of the dependency recorded
+// by the previous reconciliations, or nil when there is none.
+func previousDependencyRef[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	ref *ObjectReference,
+) *ObjectReference {
+	var previous *ObjectReference
+
+	// The dependencies are resolved concurrently
+	ReadStatus(ctx, reconciler, func(status *Status) {
+		previous, _ = status.Dependencies.Get(ref.Group, ref.Kind, ref.Name)
+	})
+
+	return previous
+}
+
+// isLost returns whether the dependency was resolved before and does not exist anymore.
+func isLost(previous *ObjectReference) bool {
+	return previous != nil && previous.UID != ""
+}
+
+// isReplaced returns whether the dependency was recreated since it was resolved.
+func isReplaced(previous *ObjectReference, uid string) bool {
+	return previous != nil && previous.UID != "" && previous.UID != uid
+}
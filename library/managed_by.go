@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"slices"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -125,25 +127,125 @@ func RemoveManagedBy(obj client.Object, controlledBy client.Object, scheme *runt
 func GetManagedByReconcileRequests(ownedBy client.Object, scheme *runtime.Scheme) (func(ctx context.Context, obj client.Object) []reconcile.Request, error) {
 	gvk, err := apiutil.GVKForObject(ownedBy, scheme)
 	return func(ctx context.Context, obj client.Object) []reconcile.Request {
-		references, err := GetManagedBy(obj)
+		dependents, err := GetDependents(obj, gvk)
 		if err != nil {
 			return nil
 		}
 
-		var requests []reconcile.Request
+		requests := make([]reconcile.Request, 0, len(dependents))
+		for _, dependent := range dependents {
+			requests = append(requests, reconcile.Request{NamespacedName: dependent})
+		}
+		return requests
+	}, err
+}
 
-		for _, ref := range references {
-			if ref.GVK != gvk {
-				continue
-			}
+// GetDependents returns the resources of the kind that manage the object, read
+// from its managed-by annotation. The version of the kind is ignored.
+func GetDependents(obj client.Object, gvk schema.GroupVersionKind) ([]types.NamespacedName, error) {
+	references, err := GetManagedBy(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var dependents []types.NamespacedName
+	for _, ref := range references {
+		if ref.GVK.GroupKind() != gvk.GroupKind() {
+			continue
+		}
+
+		dependents = append(dependents, types.NamespacedName{
+			Namespace: ref.Namespace,
+			Name:      ref.Name,
+		})
+	}
+	return dependents, nil
+}
+
+// DependenciesIndexField indexes the controller resources by the dependencies
+// recorded in their status, see IndexDependencies.
+const DependenciesIndexField = "status.dependencies"
+
+// DependenciesIndexValue returns the value of the dependencies index for the dependency.
+func DependenciesIndexValue(gk schema.GroupKind, key types.NamespacedName) string {
+	return gk.String() + "/" + key.String()
+}
+
+// DependenciesIndexer extracts the values of the dependencies index from a controller resource.
+func DependenciesIndexer(obj client.Object) []string {
+	controller, ok := obj.(ControllerResource)
+	if !ok {
+		return nil
+	}
+
+	dependencies := controller.GetStatus().Dependencies
+	values := make([]string, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		values = append(values, DependenciesIndexValue(
+			dependency.GroupVersionKind().GroupKind(),
+			types.NamespacedName{Namespace: dependency.Namespace, Name: dependency.Name},
+		))
+	}
+	return values
+}
+
+// IndexDependencies indexes the controller resources of the type of the sample by
+// their dependencies, so that ListDependents does not read every one of them.
+func IndexDependencies(ctx context.Context, indexer client.FieldIndexer, sample ControllerResource) error {
+	return indexer.IndexField(ctx, sample, DependenciesIndexField, DependenciesIndexer)
+}
 
-			requests = append(requests, reconcile.Request{
-				NamespacedName: types.NamespacedName{
-					Namespace: ref.Name,
-					Name:      ref.Namespace,
-				},
-			})
+// ListDependents lists the controller resources of the type of the list that depend
+// on the object. They must be indexed with IndexDependencies.
+func ListDependents(ctx context.Context, reader client.Reader, scheme *runtime.Scheme, obj client.Object, list client.ObjectList, opts ...client.ListOption) error {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return err
+	}
+
+	value := DependenciesIndexValue(gvk.GroupKind(), client.ObjectKeyFromObject(obj))
+	return reader.List(ctx, list, append(opts, client.MatchingFields{DependenciesIndexField: value})...)
+}
+
+// GetDependentsReconcileRequests maps the events of a dependency to the requests of
+// the controller resources of the type of the sample that depend on it, read from
+// the dependencies index. The status is authoritative: a new dependency is written
+// to it before the dependency is annotated as managed by the controller resource.
+// The controller resources must be indexed with IndexDependencies.
+func GetDependentsReconcileRequests(reader client.Reader, scheme *runtime.Scheme, sample client.Object) (func(ctx context.Context, obj client.Object) []reconcile.Request, error) {
+	gvk, err := apiutil.GVKForObject(sample, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	if _, err := scheme.New(listGVK); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		newList, err := scheme.New(listGVK)
+		if err != nil {
+			return nil
+		}
+		list, ok := newList.(client.ObjectList)
+		if !ok {
+			return nil
+		}
+
+		if err := ListDependents(ctx, reader, scheme, obj, list); err != nil {
+			logf.FromContext(ctx).Error(err, "Failed to list the dependents from the dependencies index", "kind", gvk.Kind)
+			return nil
 		}
+
+		var requests []reconcile.Request
+		_ = meta.EachListItem(list, func(item runtime.Object) error {
+			if itemObj, ok := item.(client.Object); ok {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(itemObj)})
+			}
+			return nil
+		})
+
 		return requests
-	}, err
+	}, nil
 }
@@ -0,0 +1,92 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1 "multi.ch/app/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetManagedByReconcileRequests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := appv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}
+	if _, err := library.AddManagedBy(configMap, app, scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	mapFunc, err := library.GetManagedByReconcileRequests(app, scheme)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := mapFunc(context.Background(), configMap)
+	if len(requests) != 1 || requests[0].Name != "app" || requests[0].Namespace != "default" {
+		t.Errorf("expected a request for default/app, got %+v", requests)
+	}
+}
+
+func TestListDependents(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}
+	dependent := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "dependent", Namespace: "default"}}
+	dependent.Status.Dependencies = library.ObjectReferenceList{
+		{Kind: "ConfigMap", APIVersion: "v1", Name: "settings", Namespace: "default"},
+	}
+	other := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+	other.Status.Dependencies = library.ObjectReferenceList{
+		{Kind: "ConfigMap", APIVersion: "v1", Name: "other", Namespace: "default"},
+	}
+
+	// The annotating app does not record the dependency in its status
+	annotating := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "annotating", Namespace: "default"}}
+	if _, err := library.AddManagedBy(settings, annotating, scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(settings, dependent, other, annotating).
+		WithIndex(&appv1.App{}, library.DependenciesIndexField, library.DependenciesIndexer).
+		Build()
+
+	var apps appv1.AppList
+	if err := library.ListDependents(context.Background(), reader, scheme, settings, &apps); err != nil {
+		t.Fatal(err)
+	}
+	if len(apps.Items) != 1 || apps.Items[0].Name != "dependent" {
+		t.Errorf("expected the dependent app, got %+v", apps.Items)
+	}
+
+	mapFunc, err := library.GetDependentsReconcileRequests(reader, scheme, &appv1.App{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := map[types.NamespacedName]bool{}
+	for _, req := range mapFunc(context.Background(), settings) {
+		requests[req.NamespacedName] = true
+	}
+	// The annotation alone does not make a dependent
+	if len(requests) != 1 || !requests[types.NamespacedName{Namespace: "default", Name: "dependent"}] {
+		t.Errorf("expected a request for the dependent app only, got %v", requests)
+	}
+}
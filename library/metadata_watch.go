@@ -0,0 +1,111 @@
+package library
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// watchedDependency returns the object the watches of the dependency are set up
+// with. Only the metadata of the metadata-only dependencies is cached, the
+// handlers of the dependencies read nothing else.
+func watchedDependency[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+	dependency GenericDependencyResource,
+	obj client.Object,
+) (client.Object, error) {
+	if !dependency.IsMetadataOnly() {
+		return obj, nil
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, reconciler.Scheme())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get dependency resource kind")
+	}
+
+	metadata := &metav1.PartialObjectMetadata{}
+	metadata.SetGroupVersionKind(gvk)
+	metadata.SetName(obj.GetName())
+	metadata.SetNamespace(obj.GetNamespace())
+
+	return metadata, nil
+}
+
+// readDependency reads the dependency into dep from the cache and returns the
+// object read, the managed-by annotation is written to it. Only the metadata of the
+// metadata-only dependencies is cached: dep gets the metadata, or the whole object
+// read from the API server when its readiness is waited for.
+func readDependency[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	dependency GenericDependencyResource,
+	key client.ObjectKey,
+	dep client.Object,
+) (client.Object, error) {
+	read, err := watchedDependency(reconciler, dependency, dep)
+	if err != nil {
+		return nil, err
+	}
+	if err := reconciler.Get(ctx, key, read); err != nil {
+		return nil, err
+	}
+	if !dependency.IsMetadataOnly() {
+		return read, nil
+	}
+
+	if dependency.ShouldWaitForReady() {
+		if err := reconciler.GetAPIReader().Get(ctx, key, dep); err != nil {
+			return nil, err
+		}
+		return read, nil
+	}
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(read)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert dependency resource metadata")
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fields, dep); err != nil {
+		return nil, errors.Wrap(err, "failed to convert dependency resource metadata")
+	}
+
+	return read, nil
+}
+
+// writeManagedBy writes the managed-by annotation of the dependency. The metadata
+// of a metadata-only dependency is patched, it can not be updated as a whole.
+func writeManagedBy[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	dep client.Object,
+) error {
+	if _, ok := dep.(*metav1.PartialObjectMetadata); !ok {
+		return reconciler.Update(ctx, dep)
+	}
+
+	// The resource version makes the patch fail on conflict, as the update would
+	data, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"resourceVersion": dep.GetResourceVersion(),
+			"annotations": map[string]any{
+				AnnotationRef: GetAnnotation(dep, AnnotationRef),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return reconciler.Patch(ctx, dep, client.RawPatch(types.MergePatchType, data))
+}
@@ -0,0 +1,44 @@
+package library
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	StepOutcomeSuccess     = "success"
+	StepOutcomeEarlyReturn = "early_return"
+	StepOutcomeRequeue     = "requeue"
+	StepOutcomeError       = "error"
+)
+
+var (
+	// StepDuration is a prometheus histogram which keeps track of the duration
+	// of every step executed by a Stepper, per controller and per step.
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "library_stepper_step_duration_seconds",
+		Help:    "Length of time per step per controller",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5, 10, 30},
+	}, []string{"controller", "step"})
+
+	// StepOutcomeTotal is a prometheus counter which holds the number of times
+	// a step ended with a given outcome i.e success, early_return, requeue, error.
+	StepOutcomeTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "library_stepper_step_outcome_total",
+		Help: "Total number of step outcomes per step per controller",
+	}, []string{"controller", "step", "outcome"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		StepDuration,
+		StepOutcomeTotal,
+	)
+}
+
+func observeStep(controller string, step string, result StepResult, duration time.Duration) {
+	StepDuration.WithLabelValues(controller, step).Observe(duration.Seconds())
+	StepOutcomeTotal.WithLabelValues(controller, step, result.Outcome()).Inc()
+}
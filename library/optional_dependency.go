@@ -0,0 +1,59 @@
+package library
+
+import (
+	"context"
+	"reflect"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// IsPresent returns whether the object was read from the cluster. The output of
+// an optional dependency that does not exist is left empty.
+func IsPresent(obj client.Object) bool {
+	if obj == nil {
+		return false
+	}
+	if value := reflect.ValueOf(obj); value.Kind() == reflect.Pointer && value.IsNil() {
+		return false
+	}
+
+	return obj.GetResourceVersion() != ""
+}
+
+// newAbsentDependency returns an empty dependency that only carries its name.
+func newAbsentDependency(dependency GenericDependencyResource) client.Object {
+	absent := dependency.New()
+	absent.SetName(dependency.Key().Name)
+	absent.SetNamespace(dependency.Key().Namespace)
+	return absent
+}
+
+// waitForDependency reconciles the request again when the dependency is created.
+// As the dependency does not exist, it cannot be annotated as managed by the
+// controller resource, the request is recorded as pending instead. The kind is
+// taken from the key, the object read from the cluster may not carry it.
+func waitForDependency[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+	key DependencyKey,
+	dep client.Object,
+) func(ctx context.Context, req ctrl.Request) StepResult {
+	return func(ctx context.Context, req ctrl.Request) StepResult {
+		err := watchKind(ctx, reconciler, req, NewWatchKey(key.GVK, CacheTypeEnqueueForPending), dep, func() (handler.EventHandler, error) {
+			return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+				return reconciler.PendingRequests(DependencyKey{GVK: key.GVK, NamespacedName: client.ObjectKeyFromObject(obj)})
+			}), nil
+		})
+		if err != nil {
+			return ResultInError(err)
+		}
+
+		reconciler.AddPendingDependency(key, req)
+
+		return ResultSuccess()
+	}
+}
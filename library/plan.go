@@ -0,0 +1,390 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type PlanAction string
+
+const (
+	PlanActionCreate   PlanAction = "Create"
+	PlanActionUpdate   PlanAction = "Update"
+	PlanActionDelete   PlanAction = "Delete"
+	PlanActionOrphan   PlanAction = "Orphan"
+	PlanActionRecreate PlanAction = "Recreate"
+)
+
+// FieldDiff is a field that differs between the live object and the desired one.
+type FieldDiff struct {
+	Path    string `json:"path"`
+	Live    any    `json:"live,omitempty"`
+	Desired any    `json:"desired,omitempty"`
+}
+
+// PlannedChange is a write the children steps would perform.
+type PlannedChange struct {
+	Action     PlanAction  `json:"action"`
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Name       string      `json:"name"`
+	Namespace  string      `json:"namespace"`
+	Diff       []FieldDiff `json:"diff,omitempty"`
+}
+
+// Plan is the list of the changes a reconciliation would perform on the children.
+type Plan struct {
+	Changes []PlannedChange `json:"changes"`
+}
+
+// NewPlan computes the creates, updates and deletes the children steps would
+// perform for the controller resource, without writing anything. The controller
+// resource may carry a spec that is not applied yet.
+//
+// The plan runs with a state of its own, the reconciler may be the one driving
+// the controller.
+func NewPlan[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler ReconcilerWithDynamicChildren[ControllerResourceType],
+	resource ControllerResourceType,
+) (*Plan, error) {
+	ctx = WithState(ctx)
+	reconciler.SetCustomResource(ctx, resource)
+	controller := reconciler.GetCustomResource(ctx)
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(controller)}
+
+	if withDependencies, ok := any(reconciler).(ReconcilerWithDynamicDependencies[ControllerResourceType]); ok {
+		if err := planDependencies(ctx, req, withDependencies); err != nil {
+			return nil, err
+		}
+	}
+
+	children, err := reconciler.GetChildren(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get children")
+	}
+
+	plan := &Plan{}
+	var knownRefs ObjectReferenceList
+
+	for _, child := range children {
+		desired, skip, err := generateDesiredObject(ctx, req, reconciler, child)
+		if err != nil {
+			return nil, err
+		}
+		if desired == nil {
+			continue
+		}
+
+		ref, err := EmptyObjectReference(reconciler, desired)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create child resource ref")
+		}
+		knownRefs.Set(ref)
+
+		if skip {
+			// Skipped children are only forgotten
+			continue
+		}
+
+		actual, err := GenericGetter(ctx, reconciler, desired)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		switch {
+		case isFinalizing(ctx, reconciler):
+			if actual != nil {
+				ref.DeletionPolicy = child.DeletionPolicy()
+				plan.release(ref, true)
+			}
+		case actual == nil:
+			diff, err := diffObjects(nil, desired)
+			if err != nil {
+				return nil, err
+			}
+			plan.add(PlanActionCreate, ref, diff)
+		case GetAnnotation(actual, HashAnnotation) != GetAnnotation(desired, HashAnnotation):
+			diff, err := diffObjects(actual, desired)
+			if err != nil {
+				return nil, err
+			}
+
+			immutable, err := changedImmutableFields(actual, desired, child.ImmutableFields())
+			if err != nil {
+				return nil, err
+			}
+			if len(immutable) > 0 && child.DeletionPolicy() == DeletionPolicyDelete {
+				plan.add(PlanActionRecreate, ref, diff)
+			} else {
+				plan.add(PlanActionUpdate, ref, diff)
+			}
+		case child.DriftPolicy() == DriftPolicyRevert:
+			// The drifted fields are written again
+			diff, err := diffObjects(actual, desired)
+			if err != nil {
+				return nil, err
+			}
+			if len(diff) > 0 {
+				plan.add(PlanActionUpdate, ref, diff)
+			}
+		}
+	}
+
+	if !isFinalizing(ctx, reconciler) {
+		// Children recorded in the status that are not generated anymore are released
+		for _, item := range getItemsMissingFrom(knownRefs, controller.GetStatus().ChildResources) {
+			plan.release(&item, false)
+		}
+	}
+
+	return plan, nil
+}
+
+// planDependencies reads the dependencies so that the generators can use them.
+func planDependencies[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	req ctrl.Request,
+	reconciler ReconcilerWithDynamicDependencies[ControllerResourceType],
+) error {
+	dependencies, err := reconciler.GetDependencies(ctx, req)
+	if err != nil {
+		return errors.Wrap(err, "failed to get dependencies")
+	}
+
+	dependencies, err = resolveSelectorDependencies(ctx, req, reconciler, dependencies, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve selector dependencies")
+	}
+
+	for _, dependency := range dependencies {
+		dep := dependency.New()
+		if _, err := readDependency(ctx, reconciler, dependency, dependency.Key(), dep); err != nil {
+			ref, refErr := EmptyObjectReference(reconciler, newAbsentDependency(dependency))
+			if refErr != nil {
+				return errors.Wrap(refErr, "failed to create dependency resource ref")
+			}
+
+			// Lost dependencies are planned as absent, as they are reconciled
+			if apierrors.IsNotFound(err) && (dependency.IsOptional() || isLost(previousDependencyRef(ctx, reconciler, ref))) {
+				dependency.Set(newAbsentDependency(dependency))
+				continue
+			}
+			if apierrors.IsNotFound(err) {
+				err = NewCategorizedError(ErrorCategoryDependencyMissing, err)
+			}
+			return errors.Wrapf(err, "failed to get dependency %s", dependency.Key())
+		}
+
+		dependency.Set(dep)
+	}
+
+	return nil
+}
+
+func (plan *Plan) add(action PlanAction, ref *ObjectReference, diff []FieldDiff) {
+	plan.Changes = append(plan.Changes, PlannedChange{
+		Action:     action,
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Name:       ref.Name,
+		Namespace:  ref.Namespace,
+		Diff:       diff,
+	})
+}
+
+// release plans the deletion or the orphaning of the child, retained children are
+// left untouched until the parent is deleted.
+func (plan *Plan) release(ref *ObjectReference, finalizing bool) {
+	switch ref.DeletionPolicy {
+	case DeletionPolicyRetain:
+		if finalizing {
+			plan.add(PlanActionOrphan, ref, nil)
+		}
+	case DeletionPolicyOrphan:
+		plan.add(PlanActionOrphan, ref, nil)
+	default:
+		plan.add(PlanActionDelete, ref, nil)
+	}
+}
+
+// Write prints the plan in a human readable form.
+func (plan *Plan) Write(w io.Writer) error {
+	if len(plan.Changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+
+	symbols := map[PlanAction]string{
+		PlanActionCreate:   "+",
+		PlanActionUpdate:   "~",
+		PlanActionDelete:   "-",
+		PlanActionOrphan:   "/",
+		PlanActionRecreate: "-/+",
+	}
+
+	for _, change := range plan.Changes {
+		_, err := fmt.Fprintf(w, "%s %s %s %s/%s\n", symbols[change.Action], change.Action, change.Kind, change.Namespace, change.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, diff := range change.Diff {
+			if change.Action == PlanActionCreate {
+				_, err = fmt.Fprintf(w, "    %s: %v\n", diff.Path, diff.Desired)
+			} else {
+				_, err = fmt.Fprintf(w, "    %s: %v -> %v\n", diff.Path, diff.Live, diff.Desired)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// diffObjects returns the fields set in the desired object that differ in the
+// live one. Fields only set in the live object are defaulted by the API server
+// and ignored, as are the status and the metadata other than labels and annotations.
+func diffObjects(live, desired client.Object) ([]FieldDiff, error) {
+	desiredFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert desired object")
+	}
+
+	var liveFields map[string]any
+	if live != nil {
+		liveFields, err = runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert live object")
+		}
+	}
+
+	delete(desiredFields, "status")
+	delete(desiredFields, "apiVersion")
+	delete(desiredFields, "kind")
+	if metadata, ok := desiredFields["metadata"].(map[string]any); ok {
+		kept := map[string]any{}
+		for _, key := range []string{"labels", "annotations"} {
+			if value, ok := metadata[key]; ok {
+				kept[key] = value
+			}
+		}
+		if annotations, ok := kept["annotations"].(map[string]any); ok {
+			delete(annotations, HashAnnotation)
+		}
+		desiredFields["metadata"] = kept
+	}
+
+	var diffs []FieldDiff
+	diffFields("", liveFields, desiredFields, &diffs)
+	return diffs, nil
+}
+
+func diffFields(path string, live, desired any, diffs *[]FieldDiff) {
+	switch desiredValue := desired.(type) {
+	case nil:
+		return
+	case map[string]any:
+		liveValue, ok := live.(map[string]any)
+		if !ok && live != nil {
+			break
+		}
+
+		keys := make([]string, 0, len(desiredValue))
+		for key := range desiredValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			diffFields(fieldPath(path, key), liveValue[key], desiredValue[key], diffs)
+		}
+		return
+	case []any:
+		liveValue, ok := live.([]any)
+		if !ok && live != nil {
+			break
+		}
+
+		// Only the generated entries are compared, the entries added to the live
+		// list by others, e.g. an injected sidecar, are left alone
+		if names, ok := listNames(desiredValue); ok {
+			liveItems := make(map[string]any, len(liveValue))
+			for _, item := range liveValue {
+				if name, ok := listName(item); ok {
+					liveItems[name] = item
+				}
+			}
+			for i, name := range names {
+				diffFields(fmt.Sprintf("%s[%s]", path, name), liveItems[name], desiredValue[i], diffs)
+			}
+			return
+		}
+
+		for i := range desiredValue {
+			var liveItem any
+			if i < len(liveValue) {
+				liveItem = liveValue[i]
+			}
+			diffFields(fmt.Sprintf("%s[%d]", path, i), liveItem, desiredValue[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		*diffs = append(*diffs, FieldDiff{
+			Path:    path,
+			Live:    live,
+			Desired: desired,
+		})
+	}
+}
+
+// listNames returns the names of the entries when every entry of the list is
+// keyed by a name, like the containers or the volumes of a pod.
+func listNames(list []any) ([]string, bool) {
+	names := make([]string, 0, len(list))
+	for _, item := range list {
+		name, ok := listName(item)
+		if !ok {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, len(names) > 0
+}
+
+func listName(item any) (string, bool) {
+	entry, ok := item.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	name, ok := entry["name"].(string)
+	return name, ok && name != ""
+}
+
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
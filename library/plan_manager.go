@@ -0,0 +1,56 @@
+package library
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PlanManager stands in for the manager of a reconciler computing plans outside
+// of a running controller, e.g. in an API service or a CLI. Everything is read
+// with the client, the events are dropped and nothing can be watched.
+type PlanManager struct {
+	ctrl.Manager
+
+	client client.Client
+}
+
+var _ ctrl.Manager = &PlanManager{}
+
+func NewPlanManager(c client.Client) *PlanManager {
+	return &PlanManager{client: c}
+}
+
+func (manager *PlanManager) GetClient() client.Client {
+	return manager.client
+}
+
+func (manager *PlanManager) GetAPIReader() client.Reader {
+	return manager.client
+}
+
+func (manager *PlanManager) GetScheme() *runtime.Scheme {
+	return manager.client.Scheme()
+}
+
+func (manager *PlanManager) GetRESTMapper() meta.RESTMapper {
+	return manager.client.RESTMapper()
+}
+
+func (manager *PlanManager) GetEventRecorderFor(name string) record.EventRecorder {
+	// The recorder drops the events without a channel
+	return &record.FakeRecorder{}
+}
+
+func (manager *PlanManager) GetCache() cache.Cache {
+	return &planCache{Reader: manager.client}
+}
+
+// planCache reads with the client of the plan, the plans start no informer.
+type planCache struct {
+	cache.Informers
+	client.Reader
+}
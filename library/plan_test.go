@@ -0,0 +1,94 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newPlanReconciler(t *testing.T, objects ...client.Object) *childrenReconciler {
+	reconciler := &childrenReconciler{testReconciler: newTestReconciler(t, objects...)}
+	reconciler.children = func(ctx context.Context, req ctrl.Request) []library.GenericChildResource {
+		return []library.GenericChildResource{
+			library.NewChildResource(
+				&corev1.ConfigMap{},
+				library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+					app := reconciler.GetCustomResource(ctx)
+					return &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      app.Name,
+							Namespace: app.Namespace,
+						},
+						Data: map[string]string{
+							"command": app.Spec.Command,
+						},
+					}, false, nil
+				}),
+			),
+		}
+	}
+
+	return reconciler
+}
+
+func TestPlan(t *testing.T) {
+	app := &appv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+		Spec:       appv1.AppSpec{Port: 8080, Command: "sleep 10"},
+	}
+
+	reconciler := newPlanReconciler(t, app)
+	plan, err := library.NewPlan(context.Background(), reconciler, app.DeepCopy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != library.PlanActionCreate {
+		t.Fatalf("expected the ConfigMap to be created, got %+v", plan.Changes)
+	}
+
+	// Apply the plan by hand, then change the spec
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app",
+			Namespace:   "default",
+			Annotations: map[string]string{library.HashAnnotation: "outdated"},
+		},
+		Data: map[string]string{"command": "sleep 10"},
+	}
+	reconciler = newPlanReconciler(t, app, existing)
+
+	proposed := app.DeepCopy()
+	proposed.Spec.Command = "sleep 20"
+	plan, err = library.NewPlan(context.Background(), reconciler, proposed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != library.PlanActionUpdate {
+		t.Fatalf("expected the ConfigMap to be updated, got %+v", plan.Changes)
+	}
+
+	var commandDiff *library.FieldDiff
+	for i, diff := range plan.Changes[0].Diff {
+		if diff.Path == "data.command" {
+			commandDiff = &plan.Changes[0].Diff[i]
+		}
+	}
+	if commandDiff == nil || commandDiff.Live != "sleep 10" || commandDiff.Desired != "sleep 20" {
+		t.Errorf("expected a diff on data.command, got %+v", plan.Changes[0].Diff)
+	}
+
+	// Nothing was written
+	var configMap corev1.ConfigMap
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(existing), &configMap); err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Data["command"] != "sleep 10" {
+		t.Error("the plan should not write anything")
+	}
+}
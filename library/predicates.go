@@ -0,0 +1,15 @@
+package library
+
+import "sigs.k8s.io/controller-runtime/pkg/predicate"
+
+// ControllerResourcePredicate filters out the updates of a controller resource
+// that only change its status. The stepper writes the status on every
+// reconciliation, reacting to these writes would requeue the resource right
+// away and defeat the backoff of ResultRequeueWithBackoff.
+func ControllerResourcePredicate() predicate.Predicate {
+	return predicate.Or(
+		predicate.GenerationChangedPredicate{},
+		predicate.AnnotationChangedPredicate{},
+		predicate.LabelChangedPredicate{},
+	)
+}
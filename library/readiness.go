@@ -0,0 +1,293 @@
+package library
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	readinessGettersLock sync.RWMutex
+
+	// readinessGetters are keyed by the package path and the name of the type, so
+	// that the kinds of other modules are supported without depending on them.
+	readinessGetters = map[string]func(client.Object) *Status{
+		"k8s.io/api/apps/v1.Deployment":                                  typedReadiness(deploymentReadiness),
+		"k8s.io/api/apps/v1.StatefulSet":                                 typedReadiness(statefulSetReadiness),
+		"k8s.io/api/core/v1.Service":                                     typedReadiness(serviceReadiness),
+		"k8s.io/api/core/v1.PersistentVolumeClaim":                       typedReadiness(persistentVolumeClaimReadiness),
+		"sigs.k8s.io/gateway-api/apis/v1.HTTPRoute":                      routeReadiness,
+		"github.com/envoyproxy/gateway/api/v1alpha1.Backend":             acceptedReadiness,
+		"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured.Unstructured": typedReadiness(UnstructuredReadiness),
+	}
+)
+
+// RegisterReadinessGetter makes the children of the type of the sample use the
+// getter to report their readiness. It must be called before the children are created.
+func RegisterReadinessGetter[T client.Object](sample T, getter func(T) *Status) {
+	readinessGettersLock.Lock()
+	defer readinessGettersLock.Unlock()
+
+	readinessGetters[readinessKey(sample)] = typedReadiness(getter)
+}
+
+// ReadinessGetter returns the readiness getter registered for the type of the
+// sample, or DefaultStatusGetter when there is none.
+func ReadinessGetter[T client.Object](sample T) func(T) *Status {
+	readinessGettersLock.RLock()
+	getter, ok := readinessGetters[readinessKey(sample)]
+	readinessGettersLock.RUnlock()
+
+	if !ok {
+		return DefaultStatusGetter[T]
+	}
+
+	return func(obj T) *Status {
+		return getter(obj)
+	}
+}
+
+func readinessKey(obj any) string {
+	objType := reflect.TypeOf(obj)
+	if objType == nil {
+		return ""
+	}
+	if objType.Kind() == reflect.Pointer {
+		objType = objType.Elem()
+	}
+
+	return objType.PkgPath() + "." + objType.Name()
+}
+
+func typedReadiness[T client.Object](getter func(T) *Status) func(client.Object) *Status {
+	return func(obj client.Object) *Status {
+		typed, ok := obj.(T)
+		if !ok {
+			return notReady(ReasonUnknown, fmt.Sprintf("unexpected type %T", obj))
+		}
+		return getter(typed)
+	}
+}
+
+func ready() *Status {
+	return &Status{
+		Conditions: []metav1.Condition{
+			{
+				Type:   ConditionTypeReady,
+				Status: metav1.ConditionTrue,
+			},
+		},
+	}
+}
+
+func notReady(reason, message string) *Status {
+	return &Status{
+		Conditions: []metav1.Condition{
+			{
+				Type:    ConditionTypeReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  reason,
+				Message: message,
+			},
+		},
+	}
+}
+
+// deploymentReadiness follows the checks of `kubectl rollout status`.
+func deploymentReadiness(deployment *appsv1.Deployment) *Status {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return notReady(ReasonProgressing, "waiting for the deployment spec update to be observed")
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return notReady(condition.Reason, condition.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	switch {
+	case deployment.Status.UpdatedReplicas < replicas:
+		return notReady(ReasonProgressing, fmt.Sprintf("%d out of %d new replicas have been updated", deployment.Status.UpdatedReplicas, replicas))
+	case deployment.Status.Replicas > deployment.Status.UpdatedReplicas:
+		return notReady(ReasonProgressing, fmt.Sprintf("%d old replicas are pending termination", deployment.Status.Replicas-deployment.Status.UpdatedReplicas))
+	case deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas:
+		return notReady(ReasonProgressing, fmt.Sprintf("%d of %d updated replicas are available", deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas))
+	}
+
+	return ready()
+}
+
+// statefulSetReadiness follows the checks of `kubectl rollout status`.
+func statefulSetReadiness(statefulSet *appsv1.StatefulSet) *Status {
+	if statefulSet.Generation > statefulSet.Status.ObservedGeneration {
+		return notReady(ReasonProgressing, "waiting for the statefulset spec update to be observed")
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return notReady(ReasonProgressing, fmt.Sprintf("%d of %d replicas are ready", statefulSet.Status.ReadyReplicas, replicas))
+	}
+
+	strategy := statefulSet.Spec.UpdateStrategy
+	if strategy.Type == appsv1.RollingUpdateStatefulSetStrategyType && strategy.RollingUpdate != nil && strategy.RollingUpdate.Partition != nil {
+		expected := replicas - *strategy.RollingUpdate.Partition
+		if statefulSet.Status.UpdatedReplicas < expected {
+			return notReady(ReasonProgressing, fmt.Sprintf("%d of %d replicas of the partition are updated", statefulSet.Status.UpdatedReplicas, expected))
+		}
+		return ready()
+	}
+
+	if strategy.Type != appsv1.OnDeleteStatefulSetStrategyType && statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision {
+		return notReady(ReasonProgressing, "waiting for the rolling update to complete")
+	}
+
+	return ready()
+}
+
+// serviceReadiness waits for the load balancer of the service to be provisioned.
+func serviceReadiness(service *corev1.Service) *Status {
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer && len(service.Status.LoadBalancer.Ingress) == 0 {
+		return notReady(ReasonPending, "waiting for the load balancer to be provisioned")
+	}
+
+	return ready()
+}
+
+func persistentVolumeClaimReadiness(claim *corev1.PersistentVolumeClaim) *Status {
+	if claim.Status.Phase != corev1.ClaimBound {
+		return notReady(string(claim.Status.Phase), "the claim is not bound")
+	}
+
+	return ready()
+}
+
+// routeReadiness waits for every parent gateway to accept the route and resolve its references.
+func routeReadiness(route client.Object) *Status {
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(route)
+	if err != nil {
+		return notReady(ReasonUnknown, err.Error())
+	}
+
+	parents, _, _ := unstructured.NestedSlice(fields, "status", "parents")
+	if len(parents) == 0 {
+		return notReady(ReasonNotAccepted, "waiting for the route to be accepted by a gateway")
+	}
+
+	for _, parent := range parents {
+		parentFields, ok := parent.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		conditions := nestedConditions(parentFields, "conditions")
+		for _, conditionType := range []string{"Accepted", "ResolvedRefs"} {
+			if status := conditionStatus(conditions, conditionType, route.GetGeneration()); status != nil {
+				return status
+			}
+		}
+	}
+
+	return ready()
+}
+
+// acceptedReadiness waits for the Accepted condition of the resource.
+func acceptedReadiness(obj client.Object) *Status {
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return notReady(ReasonUnknown, err.Error())
+	}
+
+	conditions := nestedConditions(fields, "status", "conditions")
+	if status := conditionStatus(conditions, "Accepted", obj.GetGeneration()); status != nil {
+		return status
+	}
+
+	return ready()
+}
+
+// UnstructuredReadiness reads the readiness of any resource following the rules
+// of kstatus. The resource is not ready while it is deleted, while its
+// status.observedGeneration or the observedGeneration of its Ready condition is
+// behind its generation, or while its Ready condition is not true. Without Ready
+// condition, the resource is not ready while its Reconciling or Stalled
+// condition is true, and ready otherwise.
+func UnstructuredReadiness(obj *unstructured.Unstructured) *Status {
+	if obj.GetDeletionTimestamp() != nil {
+		return notReady(ReasonTerminating, "the resource is being deleted")
+	}
+
+	generation := obj.GetGeneration()
+	observedGeneration, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if found && observedGeneration < generation {
+		return notReady(ReasonProgressing, fmt.Sprintf("waiting for the generation %d to be observed", generation))
+	}
+
+	conditions := nestedConditions(obj.Object, "status", "conditions")
+	if meta.FindStatusCondition(conditions, ConditionTypeReady) != nil {
+		if status := conditionStatus(conditions, ConditionTypeReady, generation); status != nil {
+			return status
+		}
+		return ready()
+	}
+
+	for _, conditionType := range []string{"Stalled", "Reconciling"} {
+		if condition := meta.FindStatusCondition(conditions, conditionType); condition != nil && condition.Status == metav1.ConditionTrue {
+			return notReady(conditionType, condition.Message)
+		}
+	}
+
+	return ready()
+}
+
+// conditionStatus returns a not ready status when the condition is missing,
+// outdated or not true.
+func conditionStatus(conditions []metav1.Condition, conditionType string, generation int64) *Status {
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	switch {
+	case condition == nil:
+		return notReady(ReasonUnknown, fmt.Sprintf("waiting for the %s condition", conditionType))
+	case condition.ObservedGeneration != 0 && condition.ObservedGeneration < generation:
+		return notReady(ReasonProgressing, fmt.Sprintf("waiting for the %s condition to observe the generation %d", conditionType, generation))
+	case condition.Status != metav1.ConditionTrue:
+		return notReady(condition.Reason, condition.Message)
+	}
+
+	return nil
+}
+
+func nestedConditions(fields map[string]any, path ...string) []metav1.Condition {
+	items, _, _ := unstructured.NestedSlice(fields, path...)
+
+	conditions := make([]metav1.Condition, 0, len(items))
+	for _, item := range items {
+		itemFields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var condition metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(itemFields, &condition); err != nil {
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions
+}
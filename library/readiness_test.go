@@ -0,0 +1,123 @@
+package library_test
+
+import (
+	"library"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func isReady(status *library.Status) bool {
+	return meta.IsStatusConditionTrue(status.Conditions, library.ConditionTypeReady)
+}
+
+func TestDeploymentReadiness(t *testing.T) {
+	getter := library.ReadinessGetter(&appsv1.Deployment{})
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: library.Opt(int32(2))},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  1,
+		},
+	}
+	if isReady(getter(deployment)) {
+		t.Error("a deployment without all its replicas available should not be ready")
+	}
+
+	deployment.Status.AvailableReplicas = 2
+	if !isReady(getter(deployment)) {
+		t.Error("a deployment with all its replicas available should be ready")
+	}
+
+	deployment.Generation = 3
+	if isReady(getter(deployment)) {
+		t.Error("a deployment whose spec update is not observed should not be ready")
+	}
+}
+
+func TestPersistentVolumeClaimReadiness(t *testing.T) {
+	getter := library.ReadinessGetter(&corev1.PersistentVolumeClaim{})
+
+	claim := &corev1.PersistentVolumeClaim{Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}}
+	if isReady(getter(claim)) {
+		t.Error("a pending claim should not be ready")
+	}
+
+	claim.Status.Phase = corev1.ClaimBound
+	if !isReady(getter(claim)) {
+		t.Error("a bound claim should be ready")
+	}
+}
+
+func TestRegisterReadinessGetter(t *testing.T) {
+	if !isReady(library.ReadinessGetter(&corev1.Secret{})(&corev1.Secret{})) {
+		t.Fatal("a kind without readiness getter should be ready")
+	}
+
+	library.RegisterReadinessGetter(&corev1.Secret{}, func(secret *corev1.Secret) *library.Status {
+		return &library.Status{}
+	})
+
+	child := library.NewChildResource(&corev1.Secret{})
+	if isReady(child.Status(&corev1.Secret{})) {
+		t.Error("the registered getter should be used by the children of its kind")
+	}
+}
+
+func TestUnstructuredReadiness(t *testing.T) {
+	cases := map[string]struct {
+		status map[string]any
+		ready  bool
+	}{
+		"ready": {
+			status: map[string]any{
+				"observedGeneration": int64(2),
+				"conditions":         []any{map[string]any{"type": "Ready", "status": "True", "observedGeneration": int64(2)}},
+			},
+			ready: true,
+		},
+		"generation not observed": {
+			status: map[string]any{
+				"observedGeneration": int64(1),
+				"conditions":         []any{map[string]any{"type": "Ready", "status": "True"}},
+			},
+		},
+		"outdated condition": {
+			status: map[string]any{
+				"conditions": []any{map[string]any{"type": "Ready", "status": "True", "observedGeneration": int64(1)}},
+			},
+		},
+		"not ready": {
+			status: map[string]any{
+				"conditions": []any{map[string]any{"type": "Ready", "status": "False", "reason": "Reconciling"}},
+			},
+		},
+		"reconciling": {
+			status: map[string]any{
+				"conditions": []any{map[string]any{"type": "Reconciling", "status": "True"}},
+			},
+		},
+		"without conditions": {
+			status: map[string]any{},
+			ready:  true,
+		},
+	}
+
+	getter := library.ReadinessGetter(&unstructured.Unstructured{})
+	for name, tc := range cases {
+		obj := &unstructured.Unstructured{Object: map[string]any{"status": tc.status}}
+		obj.SetGeneration(2)
+
+		if ready := isReady(getter(obj)); ready != tc.ready {
+			t.Errorf("%s: expected ready to be %t, got %t", name, tc.ready, ready)
+		}
+	}
+}
@@ -18,8 +18,8 @@ type ControllerResource interface {
 type Reconciler[ControllerResourceType ControllerResource] interface {
 	GetController() controller.TypedController[reconcile.Request]
 	GetFinalizer() string
-	GetCustomResource() ControllerResourceType
-	SetCustomResource(ControllerResourceType)
+	GetCustomResource(ctx context.Context) ControllerResourceType
+	SetCustomResource(ctx context.Context, resource ControllerResourceType)
 
 	client.Client
 	ctrl.Manager
@@ -0,0 +1,213 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// testReconciler is a reconciler of Apps backed by a fake client.
+type testReconciler struct {
+	ctrl.Manager
+	client.Client
+	library.WatchCache
+
+	app        *appv1.App
+	recorder   *record.FakeRecorder
+	controller *testController
+	cache      *testCache
+}
+
+// testController counts the watches instead of starting them.
+type testController struct {
+	controller.TypedController[reconcile.Request]
+
+	watches int
+}
+
+func (c *testController) Watch(src source.TypedSource[reconcile.Request]) error {
+	c.watches++
+	return nil
+}
+
+// testCache records the informers that are removed.
+type testCache struct {
+	cache.Cache
+
+	removed []client.Object
+}
+
+func (c *testCache) RemoveInformer(ctx context.Context, obj client.Object) error {
+	c.removed = append(c.removed, obj)
+	return nil
+}
+
+var _ library.Reconciler[*appv1.App] = &testReconciler{}
+
+func newTestReconciler(t *testing.T, objects ...client.Object) *testReconciler {
+	t.Helper()
+
+	return newInterceptedTestReconciler(t, interceptor.Funcs{}, objects...)
+}
+
+// newInterceptedTestReconciler creates a test reconciler whose client calls go through the funcs.
+func newInterceptedTestReconciler(t *testing.T, funcs interceptor.Funcs, objects ...client.Object) *testReconciler {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(&appv1.App{}).
+			WithObjects(objects...).
+			WithInterceptorFuncs(funcs).
+			Build(),
+		recorder:   record.NewFakeRecorder(16),
+		controller: &testController{},
+		cache:      &testCache{},
+	}
+}
+
+func (reconciler *testReconciler) GetController() controller.TypedController[reconcile.Request] {
+	return reconciler.controller
+}
+
+func (reconciler *testReconciler) GetAPIReader() client.Reader {
+	return reconciler.Client
+}
+
+func (reconciler *testReconciler) GetCache() cache.Cache {
+	return reconciler.cache
+}
+
+func (reconciler *testReconciler) GetEventRecorderFor(name string) record.EventRecorder {
+	return reconciler.recorder
+}
+
+func (reconciler *testReconciler) GetFinalizer() string {
+	return "test.multi.ch/finalizer"
+}
+
+func (reconciler *testReconciler) GetCustomResource(ctx context.Context) *appv1.App {
+	return library.GetCustomResource[*appv1.App](ctx)
+}
+
+// SetCustomResource also keeps the controller resource, so that the tests can
+// read its status once the execution ended.
+func (reconciler *testReconciler) SetCustomResource(ctx context.Context, app *appv1.App) {
+	library.SetCustomResource(ctx, app)
+	reconciler.app = app
+}
+
+// executeSteps finds the controller resource of the request, then runs the steps.
+func executeSteps(reconciler library.Reconciler[*appv1.App], req ctrl.Request, steps ...library.Step) (ctrl.Result, error) {
+	options := []library.StepperOptions{
+		library.WithReconciler(reconciler),
+		library.WithStep(library.NewFindControllerResourceStep(reconciler)),
+	}
+	for _, step := range steps {
+		options = append(options, library.WithStep(step))
+	}
+
+	return library.NewStepper(logr.Discard(), options...).Execute(context.Background(), req)
+}
+
+// childrenReconciler reconciles the children returned by the function.
+type childrenReconciler struct {
+	*testReconciler
+
+	children func(ctx context.Context, req ctrl.Request) []library.GenericChildResource
+}
+
+func (reconciler *childrenReconciler) GetChildren(ctx context.Context, req ctrl.Request) ([]library.GenericChildResource, error) {
+	return reconciler.children(ctx, req), nil
+}
+
+// dependenciesReconciler resolves the dependencies returned by the function.
+type dependenciesReconciler struct {
+	*testReconciler
+
+	dependencies func(ctx context.Context, req ctrl.Request) []library.GenericDependencyResource
+}
+
+func (reconciler *dependenciesReconciler) GetDependencies(ctx context.Context, req ctrl.Request) ([]library.GenericDependencyResource, error) {
+	return reconciler.dependencies(ctx, req), nil
+}
+
+// dependencyReconciler depends on the ConfigMaps of the namespace of the
+// request with the names, or on the ones matching the selector when it is set.
+// The settings ConfigMap is read in configMap, the matches in matches.
+type dependencyReconciler struct {
+	*testReconciler
+
+	names    []string
+	selector labels.Selector
+	options  []library.DependencyResourceOption[*corev1.ConfigMap]
+
+	configMap corev1.ConfigMap
+	matches   *library.SelectorDependencyResource[*corev1.ConfigMap]
+}
+
+func newDependencyReconciler(t *testing.T, objects []client.Object, options ...library.DependencyResourceOption[*corev1.ConfigMap]) *dependencyReconciler {
+	t.Helper()
+
+	return &dependencyReconciler{
+		testReconciler: newTestReconciler(t, objects...),
+		names:          []string{"settings"},
+		options:        options,
+	}
+}
+
+func (reconciler *dependencyReconciler) GetDependencies(ctx context.Context, req ctrl.Request) ([]library.GenericDependencyResource, error) {
+	options := append([]library.DependencyResourceOption[*corev1.ConfigMap]{
+		library.WithNamespace[*corev1.ConfigMap](req.Namespace),
+	}, reconciler.options...)
+
+	if reconciler.selector != nil {
+		reconciler.matches = library.NewSelectorDependencyResource(&corev1.ConfigMap{}, reconciler.selector, options...)
+		return []library.GenericDependencyResource{reconciler.matches}, nil
+	}
+
+	var dependencies []library.GenericDependencyResource
+	for _, name := range reconciler.names {
+		output := &corev1.ConfigMap{}
+		if name == "settings" {
+			output = &reconciler.configMap
+		}
+
+		dependencies = append(dependencies, library.NewDependencyResource(
+			&corev1.ConfigMap{},
+			append(options, library.WithName[*corev1.ConfigMap](name), library.WithOutput(output))...,
+		))
+	}
+
+	return dependencies, nil
+}
+
+// resolve resolves the dependencies of the request, then runs the steps.
+func (reconciler *dependencyReconciler) resolve(req ctrl.Request, steps ...library.Step) (ctrl.Result, error) {
+	return executeSteps(reconciler, req, append([]library.Step{library.NewResolveDynamicDependenciesStep(reconciler)}, steps...)...)
+}
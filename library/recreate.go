@@ -0,0 +1,114 @@
+package library
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const immutableFieldMessage = "field is immutable"
+
+// changedImmutableFields returns the immutable fields, or the fields below
+// them, whose desired value differs from the live one.
+func changedImmutableFields(actual, desired client.Object, immutableFields []string) ([]string, error) {
+	if len(immutableFields) == 0 {
+		return nil, nil
+	}
+
+	diffs, err := diffObjects(actual, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, diff := range diffs {
+		for _, field := range immutableFields {
+			if diff.Path == field || strings.HasPrefix(diff.Path, field+".") || strings.HasPrefix(diff.Path, field+"[") {
+				changed = append(changed, diff.Path)
+				break
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+// immutableFieldError returns whether the API server rejected the write
+// because it changes immutable fields, and which ones.
+func immutableFieldError(err error) ([]string, bool) {
+	if !apierrors.IsInvalid(err) {
+		return nil, false
+	}
+
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return nil, strings.Contains(err.Error(), immutableFieldMessage)
+	}
+
+	var fields []string
+	for _, cause := range status.Status().Details.Causes {
+		if strings.Contains(cause.Message, immutableFieldMessage) {
+			fields = append(fields, cause.Field)
+		}
+	}
+
+	return fields, len(fields) > 0
+}
+
+// recreateChild deletes the child and creates it again, as the changed fields
+// cannot be updated. The recreation is recorded on the child reference. The
+// children with an Orphan or Retain deletion policy are never deleted.
+func recreateChild[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	req ctrl.Request,
+	reconciler Reconciler[ControllerResourceType],
+	child GenericChildResource,
+	childRef *ObjectReference,
+	desired client.Object,
+	actual client.Object,
+	fields []string,
+) (client.Object, StepResult) {
+	// Only the children deleted with their parent may be deleted to be recreated
+	if child.DeletionPolicy() != DeletionPolicyDelete {
+		err := errors.Errorf("the immutable fields %s of the child cannot be changed with the %s deletion policy", strings.Join(fields, ", "), child.DeletionPolicy())
+		return nil, ResultTerminal(NewCategorizedError(ErrorCategoryInvalidSpec, err))
+	}
+
+	// Do not delete a child that was replaced in the meantime
+	uid := actual.GetUID()
+	err := reconciler.Delete(ctx, actual, client.Preconditions{UID: &uid})
+	if client.IgnoreNotFound(err) != nil {
+		return nil, ResultInError(errors.Wrap(err, "failed to delete child resource to recreate it"))
+	}
+
+	childRef.RecreatedAt = Opt(metav1.Now())
+	childRef.RecreatedFields = fields
+
+	// The child may have finalizers delaying its deletion
+	err = reconciler.Get(ctx, client.ObjectKeyFromObject(actual), NewInstanceOf(actual))
+	if err == nil {
+		return nil, waitForChildDeletion(ctx, req)
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, ResultInError(errors.Wrap(err, "failed to get child resource"))
+	}
+
+	desired.SetResourceVersion("")
+	desired.SetUID("")
+	if err := writeChild(ctx, reconciler, child, desired, nil); err != nil {
+		return nil, ResultInError(err)
+	}
+
+	return desired, ResultSuccess()
+}
+
+func waitForChildDeletion(ctx context.Context, req ctrl.Request) StepResult {
+	return ResultRequeueWithBackoff(ctx, req).WithCause(ErrorCategoryTransient, errors.New("the child resource is being deleted"))
+}
@@ -0,0 +1,221 @@
+package library
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// GenericSelectorDependencyResource is a dependency on every object matching a
+// label selector. It is resolved to a dependency for each match.
+type GenericSelectorDependencyResource interface {
+	GenericDependencyResource
+	Selector() labels.Selector
+	Resolve(ctx context.Context, reader client.Reader, scheme *runtime.Scheme) ([]GenericDependencyResource, error)
+}
+
+var _ GenericSelectorDependencyResource = &SelectorDependencyResource[client.Object]{}
+
+type SelectorDependencyResource[T client.Object] struct {
+	*DependencyResource[T]
+	selector labels.Selector
+	gvk      schema.GroupVersionKind
+	items    []T
+}
+
+// NewSelectorDependencyResource creates a dependency on the objects of the type of
+// the sample matching the selector, in the namespace set with WithNamespace or in
+// every namespace without it. The options apply to every match, WithName and
+// WithOutput are ignored, the matches are read with Items.
+func NewSelectorDependencyResource[T client.Object](sample T, selector labels.Selector, opts ...DependencyResourceOption[T]) *SelectorDependencyResource[T] {
+	return &SelectorDependencyResource[T]{
+		DependencyResource: NewDependencyResource(sample, opts...),
+		selector:           selector,
+	}
+}
+
+// NewUntypedSelectorDependencyResource creates a dependency on the objects of the
+// kind matching the selector.
+func NewUntypedSelectorDependencyResource(gvk schema.GroupVersionKind, selector labels.Selector, opts ...DependencyResourceOption[*unstructured.Unstructured]) *SelectorDependencyResource[*unstructured.Unstructured] {
+	c := NewSelectorDependencyResource(&unstructured.Unstructured{}, selector, opts...)
+	c.gvk = gvk
+
+	return c
+}
+
+func (c *SelectorDependencyResource[T]) New() client.Object {
+	obj := c.DependencyResource.New()
+	if !c.gvk.Empty() {
+		obj.GetObjectKind().SetGroupVersionKind(c.gvk)
+	}
+	return obj
+}
+
+func (c *SelectorDependencyResource[T]) Kind() string {
+	if !c.gvk.Empty() {
+		return c.gvk.Kind
+	}
+	return c.DependencyResource.Kind()
+}
+
+func (c *SelectorDependencyResource[T]) Selector() labels.Selector {
+	return c.selector
+}
+
+// Items returns the objects matching the selector, sorted by namespace and name.
+// They are read once the dependencies are resolved.
+func (c *SelectorDependencyResource[T]) Items() []T {
+	return c.items
+}
+
+// Resolve lists the objects matching the selector and returns a dependency for each of them.
+// Only the metadata of the metadata-only dependencies is listed, each match is read
+// by its own dependency.
+func (c *SelectorDependencyResource[T]) Resolve(ctx context.Context, reader client.Reader, scheme *runtime.Scheme) ([]GenericDependencyResource, error) {
+	gvk, err := apiutil.GVKForObject(c.New(), scheme)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get dependency resource kind")
+	}
+
+	var list client.ObjectList = &unstructured.UnstructuredList{}
+	if c.metadataOnly {
+		list = &metav1.PartialObjectMetadataList{}
+	}
+	list.GetObjectKind().SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	err = reader.List(ctx, list, client.InNamespace(c.namespace), client.MatchingLabelsSelector{Selector: c.selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list %s matching %s", gvk.Kind, c.selector)
+	}
+
+	var keys []types.NamespacedName
+	err = meta.EachListItem(list, func(item runtime.Object) error {
+		if obj, ok := item.(client.Object); ok {
+			keys = append(keys, client.ObjectKeyFromObject(obj))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s matching %s", gvk.Kind, c.selector)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Namespace != keys[j].Namespace {
+			return keys[i].Namespace < keys[j].Namespace
+		}
+		return keys[i].Name < keys[j].Name
+	})
+
+	c.items = make([]T, 0, len(keys))
+	dependencies := make([]GenericDependencyResource, 0, len(keys))
+	for _, key := range keys {
+		output := NewInstanceOf(c.output)
+		c.items = append(c.items, output)
+
+		dependency := &DependencyResource[T]{
+			statusGetter: c.statusGetter,
+			output:       output,
+			waitForReady: c.waitForReady,
+			metadataOnly: c.metadataOnly,
+			name:         key.Name,
+			namespace:    key.Namespace,
+		}
+
+		if c.gvk.Empty() {
+			dependencies = append(dependencies, dependency)
+		} else {
+			dependencies = append(dependencies, &UntypedDependencyResource{DependencyResource: any(dependency).(*DependencyResource[*unstructured.Unstructured]), gvk: c.gvk})
+		}
+	}
+
+	return dependencies, nil
+}
+
+// resolveSelectorDependencies replaces the selector dependencies by a dependency
+// for each object they match. The kinds of the selectors are watched, so that the
+// objects starting or stopping to match reconcile the controller resource again.
+func resolveSelectorDependencies[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	req ctrl.Request,
+	reconciler Reconciler[ControllerResourceType],
+	dependencies []GenericDependencyResource,
+	watch bool,
+) ([]GenericDependencyResource, error) {
+	resolved := make([]GenericDependencyResource, 0, len(dependencies))
+	var selectors []SelectorKey
+
+	for _, dependency := range dependencies {
+		selectorDependency, ok := dependency.(GenericSelectorDependencyResource)
+		if !ok {
+			resolved = append(resolved, dependency)
+			continue
+		}
+
+		// The selectors are resolved from the cache, the metadata-only ones from the
+		// cache of the metadata
+		matches, err := selectorDependency.Resolve(ctx, reconciler, reconciler.Scheme())
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, matches...)
+
+		if !watch {
+			continue
+		}
+
+		gvk, err := apiutil.GVKForObject(selectorDependency.New(), reconciler.Scheme())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get dependency resource kind")
+		}
+		watched, err := watchedDependency(reconciler, selectorDependency, selectorDependency.New())
+		if err != nil {
+			return nil, err
+		}
+		if err := watchSelector(ctx, reconciler, req, watched, gvk); err != nil {
+			return nil, err
+		}
+
+		selectors = append(selectors, SelectorKey{
+			GVK:       gvk,
+			Namespace: selectorDependency.Key().Namespace,
+			Selector:  selectorDependency.Selector().String(),
+		})
+	}
+
+	if watch {
+		reconciler.SetSelectorDependencies(req, selectors)
+	}
+
+	return resolved, nil
+}
+
+// watchSelector enqueues the requests whose selectors match the objects of the kind.
+func watchSelector[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	req reconcile.Request,
+	object client.Object,
+	gvk schema.GroupVersionKind,
+) error {
+	return watchKind(ctx, reconciler, req, NewWatchKey(gvk, CacheTypeEnqueueForSelector), object, func() (handler.EventHandler, error) {
+		return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			return reconciler.SelectorRequests(gvk, obj)
+		}), nil
+	})
+}
@@ -0,0 +1,88 @@
+package library
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type stateContextKey struct{}
+
+// State holds the controller resource and the outputs of a single
+// reconciliation. It is carried by the context of the steps, so that nothing of
+// a reconciliation is kept in the fields of the reconciler and several requests
+// can be reconciled concurrently.
+type State struct {
+	lock           sync.Mutex
+	customResource client.Object
+	values         map[reflect.Type]any
+}
+
+// WithState returns a context carrying a new state, unless it carries one
+// already. The stepper executions and the plans start with a new state.
+func WithState(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stateContextKey{}).(*State); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, stateContextKey{}, &State{
+		values: map[reflect.Type]any{},
+	})
+}
+
+func stateFrom(ctx context.Context) *State {
+	state, ok := ctx.Value(stateContextKey{}).(*State)
+	if !ok {
+		panic("library: the context does not carry a reconciliation state, see WithState")
+	}
+
+	return state
+}
+
+// GetCustomResource returns the controller resource of the reconciliation, an
+// empty one until it is set.
+func GetCustomResource[ControllerResourceType ControllerResource](ctx context.Context) ControllerResourceType {
+	state := stateFrom(ctx)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	if resource, ok := state.customResource.(ControllerResourceType); ok {
+		return resource
+	}
+
+	var resource ControllerResourceType
+	resource = NewInstanceOf(resource)
+	state.customResource = resource
+
+	return resource
+}
+
+// SetCustomResource sets the controller resource of the reconciliation.
+func SetCustomResource[ControllerResourceType ControllerResource](ctx context.Context, resource ControllerResourceType) {
+	state := stateFrom(ctx)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	state.customResource = resource
+}
+
+// GetStateValue returns the value of the type held by the state of the
+// reconciliation, a zero value is held from the first call. The reconcilers keep
+// the outputs of their dependencies and children in it, in a type of their own.
+func GetStateValue[T any](ctx context.Context) *T {
+	state := stateFrom(ctx)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	key := reflect.TypeFor[T]()
+	if value, ok := state.values[key].(*T); ok {
+		return value
+	}
+
+	value := new(T)
+	state.values[key] = value
+
+	return value
+}
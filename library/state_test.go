@@ -0,0 +1,44 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	appv1 "multi.ch/app/api/v1"
+)
+
+func TestState(t *testing.T) {
+	first := library.WithState(context.Background())
+	second := library.WithState(context.Background())
+
+	// The controller resource is empty until it is set
+	app := library.GetCustomResource[*appv1.App](first)
+	if app == nil || app.Name != "" {
+		t.Fatalf("expected an empty controller resource, got %+v", app)
+	}
+	app.Name = "first"
+	library.SetCustomResource(second, &appv1.App{})
+	library.GetCustomResource[*appv1.App](second).Name = "second"
+
+	if name := library.GetCustomResource[*appv1.App](first).Name; name != "first" {
+		t.Errorf("expected the controller resource of the first reconciliation, got %s", name)
+	}
+	if name := library.GetCustomResource[*appv1.App](second).Name; name != "second" {
+		t.Errorf("expected the controller resource of the second reconciliation, got %s", name)
+	}
+
+	// A context carrying a state keeps it
+	if library.WithState(first) != first {
+		t.Error("expected the state to be kept")
+	}
+
+	library.GetStateValue[corev1.ConfigMap](first).Name = "settings"
+	if name := library.GetStateValue[corev1.ConfigMap](first).Name; name != "settings" {
+		t.Errorf("expected the value to be held by the state, got %q", name)
+	}
+	if name := library.GetStateValue[corev1.ConfigMap](second).Name; name != "" {
+		t.Errorf("expected the value of the second reconciliation to be empty, got %q", name)
+	}
+}
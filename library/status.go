@@ -9,4 +9,18 @@ type Status struct {
 	ChildResources ObjectReferenceList `json:"childResources,omitempty"`
 	Conditions     []metav1.Condition  `json:"conditions,omitempty"`
 	LastStep       string              `json:"lastStep,omitempty"`
+
+	// Progress is the number of steps completed over the total number of steps
+	// during the last reconciliation, e.g. "2/4".
+	Progress string `json:"progress,omitempty"`
+
+	// ConsecutiveFailures is the number of consecutive times the last step
+	// was requeued with backoff.
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+	// History keeps the outcome of the most recent reconciliations.
+	History []ReconcileRecord `json:"history,omitempty"`
+
+	// OrphanedResources are the children left behind by their deletion policy.
+	OrphanedResources ObjectReferenceList `json:"orphanedResources,omitempty"`
 }
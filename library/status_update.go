@@ -0,0 +1,316 @@
+package library
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type statusLockContextKey struct{}
+
+// withStatusLock returns a context in which the status updates are serialized.
+func withStatusLock(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(statusLockContextKey{}).(*sync.Mutex); ok {
+		return ctx
+	}
+	if _, ok := ctx.Value(statusBatchContextKey{}).(*statusBatch); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, statusLockContextKey{}, &sync.Mutex{})
+}
+
+type statusBatchContextKey struct{}
+
+// statusBatch collects the status changes of a stepper execution, they are
+// written in a single patch when the execution ends.
+type statusBatch struct {
+	lock     sync.Mutex
+	baseline client.Object
+
+	// mutations made since the baseline, replayed on the latest status on conflict
+	mutations []func(status *Status) bool
+}
+
+func newStatusBatch(ctx context.Context) (context.Context, *statusBatch) {
+	batch := &statusBatch{}
+	return context.WithValue(ctx, statusBatchContextKey{}, batch), batch
+}
+
+// snapshot records the status the changes are computed against.
+func (batch *statusBatch) snapshot(controller client.Object) {
+	batch.baseline, _ = controller.DeepCopyObject().(client.Object)
+	batch.mutations = nil
+}
+
+// apply applies the mutation to the status of the controller resource and
+// records it. The lock of the batch must be held.
+func (batch *statusBatch) apply(controller ControllerResource, mutate func(status *Status) bool) {
+	if batch.baseline == nil {
+		batch.snapshot(controller)
+	}
+
+	mutate(controller.GetStatus())
+	batch.mutations = append(batch.mutations, mutate)
+}
+
+// snapshotStatus records the status of the controller resource as it was read
+// from the cluster, the changes made during the execution are computed against it.
+func snapshotStatus(ctx context.Context, controller client.Object) {
+	if batch, ok := ctx.Value(statusBatchContextKey{}).(*statusBatch); ok {
+		batch.lock.Lock()
+		defer batch.lock.Unlock()
+
+		batch.snapshot(controller)
+	}
+}
+
+// UpdateStatus applies the mutation to the status of the controller resource.
+// During a stepper execution bound to the reconciler, the change is written with
+// the other changes at the end of the execution. Otherwise it is written right
+// away when the mutation reports a change. It is safe to call from steps running
+// concurrently.
+func UpdateStatus[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	mutate func(status *Status) bool,
+) error {
+	controller := reconciler.GetCustomResource(ctx)
+
+	if batch, ok := ctx.Value(statusBatchContextKey{}).(*statusBatch); ok {
+		batch.lock.Lock()
+		defer batch.lock.Unlock()
+
+		batch.apply(controller, mutate)
+		return nil
+	}
+
+	if lock, ok := ctx.Value(statusLockContextKey{}).(*sync.Mutex); ok {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	if !mutate(controller.GetStatus()) {
+		return nil
+	}
+
+	// Update a copy so that the response is not decoded into an object read by other steps
+	updated, ok := controller.DeepCopyObject().(client.Object)
+	if !ok {
+		return errors.New("failed to copy controller resource")
+	}
+
+	if err := reconciler.Status().Update(ctx, updated); err != nil {
+		return errors.Wrap(err, "failed to update status")
+	}
+
+	controller.SetResourceVersion(updated.GetResourceVersion())
+
+	return nil
+}
+
+// flushStatus writes the status changes collected so far by the stepper execution,
+// for the changes the other controllers must see before the execution goes on.
+func flushStatus[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+) error {
+	batch, ok := ctx.Value(statusBatchContextKey{}).(*statusBatch)
+	if !ok {
+		return nil
+	}
+
+	return batch.flush(ctx, reconciler, reconciler.GetCustomResource(ctx))
+}
+
+// ReadStatus calls read with the status of the controller resource, under the
+// same lock as UpdateStatus. It is safe to call from steps running concurrently.
+func ReadStatus[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	read func(status *Status),
+) {
+	controller := reconciler.GetCustomResource(ctx)
+
+	if batch, ok := ctx.Value(statusBatchContextKey{}).(*statusBatch); ok {
+		batch.lock.Lock()
+		defer batch.lock.Unlock()
+	} else if lock, ok := ctx.Value(statusLockContextKey{}).(*sync.Mutex); ok {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	read(controller.GetStatus())
+}
+
+// flush writes the status changes collected since the snapshot in a single
+// merge patch. On conflict, the mutations are replayed on the latest status of
+// the controller resource and the patch is computed again against it.
+func (batch *statusBatch) flush(ctx context.Context, c client.Client, controller ControllerResource) error {
+	batch.lock.Lock()
+	defer batch.lock.Unlock()
+
+	if batch.baseline == nil {
+		return nil
+	}
+
+	statusPatch, err := statusMergePatch(batch.baseline, controller)
+	if err != nil {
+		return err
+	}
+	if statusPatch == nil {
+		return nil
+	}
+
+	resourceVersion := batch.baseline.GetResourceVersion()
+	attempt := 0
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if attempt > 0 {
+			latest, err := batch.rebase(ctx, c, controller)
+			if err != nil {
+				return err
+			}
+
+			statusPatch, err = statusMergePatch(latest, controller)
+			if err != nil {
+				return err
+			}
+			resourceVersion = latest.GetResourceVersion()
+			if statusPatch == nil {
+				// The other writer already made the same changes
+				controller.SetResourceVersion(resourceVersion)
+				return nil
+			}
+		}
+		attempt++
+
+		// The merge patch replaces whole lists, the resource version makes it
+		// fail on conflict instead of overwriting changes it was not computed against
+		data, err := json.Marshal(map[string]any{
+			"metadata": map[string]any{
+				"resourceVersion": resourceVersion,
+			},
+			"status": statusPatch,
+		})
+		if err != nil {
+			return err
+		}
+
+		patched, ok := controller.DeepCopyObject().(client.Object)
+		if !ok {
+			return errors.New("failed to copy controller resource")
+		}
+		if err := c.Status().Patch(ctx, patched, client.RawPatch(types.MergePatchType, data)); err != nil {
+			return err
+		}
+
+		controller.SetResourceVersion(patched.GetResourceVersion())
+		return nil
+	})
+	if apierrors.IsNotFound(err) {
+		// The controller resource was deleted at the end of its finalization
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to patch status")
+	}
+
+	batch.snapshot(controller)
+
+	return nil
+}
+
+// rebase reads the latest version of the controller resource, replaces the
+// status of the controller resource with its status and replays the mutations
+// on it. It returns the latest version the patch is computed against.
+func (batch *statusBatch) rebase(ctx context.Context, c client.Client, controller ControllerResource) (client.Object, error) {
+	latest, ok := controller.DeepCopyObject().(client.Object)
+	if !ok {
+		return nil, errors.New("failed to copy controller resource")
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(controller), latest); err != nil {
+		return nil, err
+	}
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(controller)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert controller resource")
+	}
+	latestFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(latest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert latest controller resource")
+	}
+	fields["status"] = latestFields["status"]
+
+	// The controller resource is changed in place, the mutations may refer to it
+	rebased := NewInstanceOf(controller)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fields, rebased); err != nil {
+		return nil, errors.Wrap(err, "failed to convert controller resource")
+	}
+	reflect.ValueOf(controller).Elem().Set(reflect.ValueOf(rebased).Elem())
+
+	for _, mutate := range batch.mutations {
+		mutate(controller.GetStatus())
+	}
+
+	return latest, nil
+}
+
+// statusMergePatch returns the JSON merge patch from the status of the baseline
+// to the status of the controller resource, or nil when the status did not change.
+func statusMergePatch(baseline, controller client.Object) (json.RawMessage, error) {
+	original, err := statusJSON(baseline)
+	if err != nil {
+		return nil, err
+	}
+
+	modified, err := statusJSON(controller)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := jsonpatch.CreateMergePatch(original, modified)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute status patch")
+	}
+
+	var changes map[string]any
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return patch, nil
+}
+
+func statusJSON(obj client.Object) ([]byte, error) {
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert controller resource")
+	}
+
+	status, ok := fields["status"]
+	if !ok || status == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(status)
+}
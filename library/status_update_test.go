@@ -0,0 +1,137 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestBatchedStatus(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+
+	var patches, updates int
+	conflict := true
+	reconciler := newInterceptedTestReconciler(t, interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			patches++
+			if conflict {
+				// Another writer changed the resource since it was read
+				conflict = false
+				return apierrors.NewConflict(schema.GroupResource{Group: "app.multi.ch", Resource: "apps"}, obj.GetName(), nil)
+			}
+			return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+		},
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			updates++
+			return c.SubResource(subResource).Update(ctx, obj, opts...)
+		},
+	}, app)
+
+	setLastStep := func(name string) library.Step {
+		return library.NewStep(name, func(ctx context.Context, req ctrl.Request) library.StepResult {
+			err := library.UpdateStatus(ctx, reconciler, func(status *library.Status) bool {
+				status.ChildResources.Set(&library.ObjectReference{Kind: "ConfigMap", Name: name, Namespace: "default"})
+				return true
+			})
+			if err != nil {
+				return library.ResultInError(err)
+			}
+			return library.ResultSuccess()
+		})
+	}
+
+	execute := func() {
+		_, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)},
+			setLastStep("first"),
+			setLastStep("second"),
+			library.NewEndStep(reconciler),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	execute()
+	if updates != 0 || patches != 2 {
+		t.Fatalf("expected a single status patch retried once, got %d patches and %d updates", patches, updates)
+	}
+
+	var live appv1.App
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &live); err != nil {
+		t.Fatal(err)
+	}
+	if len(live.Status.ChildResources) != 2 || live.Status.LastStep != library.StepEndReconciliation {
+		t.Errorf("expected the status changes of every step to be written, got %+v", live.Status)
+	}
+
+	execute()
+	if patches != 2 {
+		t.Errorf("expected no status write when the status did not change, got %d patches", patches)
+	}
+}
+
+func TestBatchedStatusConflict(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+
+	conflict := true
+	reconciler := newInterceptedTestReconciler(t, interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			if conflict {
+				// Another writer adds a child to the list since the resource was read
+				conflict = false
+
+				var current appv1.App
+				if err := c.Get(ctx, client.ObjectKeyFromObject(obj), &current); err != nil {
+					return err
+				}
+				current.Status.ChildResources.Set(&library.ObjectReference{Kind: "Secret", Name: "external", Namespace: "default"})
+				if err := c.Status().Update(ctx, &current); err != nil {
+					return err
+				}
+			}
+			return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+		},
+	}, app)
+
+	child := library.NewStep("Child", func(ctx context.Context, req ctrl.Request) library.StepResult {
+		err := library.UpdateStatus(ctx, reconciler, func(status *library.Status) bool {
+			status.ChildResources.Set(&library.ObjectReference{Kind: "ConfigMap", Name: "app", Namespace: "default"})
+			return true
+		})
+		if err != nil {
+			return library.ResultInError(err)
+		}
+		return library.ResultSuccess()
+	})
+	if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, child, library.NewEndStep(reconciler)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if conflict {
+		t.Fatal("expected the status patch to conflict")
+	}
+
+	var live appv1.App
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &live); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"external", "app"} {
+		found := false
+		for _, ref := range live.Status.ChildResources {
+			found = found || ref.Name == name
+		}
+		if !found {
+			t.Errorf("expected the %s child to be kept, got %+v", name, live.Status.ChildResources)
+		}
+	}
+	if live.Status.LastStep != library.StepEndReconciliation {
+		t.Errorf("expected the changes of the execution to be written, got %+v", live.Status)
+	}
+}
@@ -3,13 +3,19 @@ package library
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/rxwycdh/rxhash"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 const (
@@ -23,6 +29,8 @@ func GenericGetter[
 	ChildType client.Object,
 ](ctx context.Context, reconciler Reconciler[ControllerResourceType], desired ChildType) (actual client.Object, err error) {
 	actual = NewInstanceOf(desired)
+	// Unstructured objects need their GVK to be read
+	actual.GetObjectKind().SetGroupVersionKind(desired.GetObjectKind().GroupVersionKind())
 	err = reconciler.Get(ctx, client.ObjectKey{
 		Name:      desired.GetName(),
 		Namespace: desired.GetNamespace(),
@@ -43,8 +51,7 @@ func NewReconcileChildStep[
 	return Step{
 		Name: fmt.Sprintf(StepReconcileChild, child.Kind()),
 		Step: func(ctx context.Context, req ctrl.Request) StepResult {
-			controller := reconciler.GetCustomResource()
-			controllerStatus := controller.GetStatus()
+			controller := reconciler.GetCustomResource(ctx)
 
 			desired, result := getDesiredObject(reconciler, child)(ctx, req)
 			if result.ShouldReturn() {
@@ -55,6 +62,15 @@ func NewReconcileChildStep[
 			if err != nil {
 				return ResultInError(errors.Wrap(err, "failed to create child resource ref"))
 			}
+			childRef.DeletionPolicy = child.DeletionPolicy()
+			setSpanObject(ctx, childRef.GroupVersionKind(), childRef.Name, childRef.Namespace)
+
+			// The watch is set up before the child is read from the cache, the
+			// reference it takes keeps the informer of the kind from being released
+			result = SetupWatch(reconciler, desired, false)(ctx, req)
+			if result.ShouldReturn() {
+				return result.FromSubStep()
+			}
 
 			actual, err := GenericGetter(ctx, reconciler, desired)
 			if client.IgnoreNotFound(err) != nil {
@@ -63,27 +79,43 @@ func NewReconcileChildStep[
 
 			requiresCreation := actual == nil
 
-			result = handleFinalization(reconciler, childRef, actual, requiresCreation)(ctx, req)
+			result = handleFinalization(reconciler, childRef, actual)(ctx, req)
 			if result.ShouldReturn() {
 				return result.FromSubStep()
 			}
+			if isFinalizing(ctx, reconciler) {
+				// The child was released, it is not reconciled anymore
+				if actual != nil {
+					child.Set(actual)
+				}
+				return ResultSuccess()
+			}
 
-			// Setup watch if not already set
-			result = SetupWatch(reconciler, desired, false)(ctx, req)
-			if result.ShouldReturn() {
-				return result.FromSubStep()
+			revert := false
+			if !requiresCreation && child.DriftPolicy() != DriftPolicyIgnore &&
+				GetAnnotation(actual, HashAnnotation) == GetAnnotation(desired, HashAnnotation) {
+				drifted, err := detectDrift(actual, desired)
+				if err != nil {
+					return ResultInError(errors.Wrap(err, "failed to detect child resource drift"))
+				}
+
+				// The fields are recorded even when reverted, the next execution clears them
+				childRef.DriftedFields = drifted
+				revert = child.DriftPolicy() == DriftPolicyRevert && len(drifted) > 0
 			}
 
-			resource, result := handleCreateOrUpdate(reconciler, desired, actual, requiresCreation)(ctx, req)
+			resource, result := handleCreateOrUpdate(reconciler, child, childRef, desired, actual, requiresCreation, revert)(ctx, req)
 			if result.ShouldReturn() {
-				childRef.Reason = result.err.Error()
+				if category, cause, failed := result.failure(); failed {
+					childRef.Reason = string(category)
+					childRef.Message = cause.Error()
+				}
 				childRef.Status = metav1.ConditionFalse
-				changed := controllerStatus.ChildResources.Set(childRef)
-				if changed {
-					err := reconciler.Status().Update(ctx, controller)
-					if err != nil {
-						return ResultInError(errors.Wrap(err, "failed to update status"))
-					}
+				err := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+					return setChildRef(status, childRef)
+				})
+				if err != nil {
+					return ResultInError(err)
 				}
 
 				return result.FromSubStep()
@@ -102,6 +134,17 @@ func NewReconcileChildStep[
 	}
 }
 
+// setChildRef records the child in the status, keeping its last recreation.
+func setChildRef(status *Status, childRef *ObjectReference) bool {
+	if previous, ok := status.ChildResources.Get(childRef.Group, childRef.Kind, childRef.Name); ok && childRef.RecreatedAt == nil {
+		childRef.RecreatedAt = previous.RecreatedAt
+		childRef.RecreatedFields = previous.RecreatedFields
+	}
+
+	changed := status.ChildResources.Set(childRef)
+	return setDriftedCondition(status) || changed
+}
+
 func waitForChildReady[
 	ControllerResourceType ControllerResource,
 ](
@@ -111,9 +154,6 @@ func waitForChildReady[
 	resource client.Object,
 ) func(ctx context.Context, req ctrl.Request) StepResult {
 	return func(ctx context.Context, req ctrl.Request) StepResult {
-		controller := reconciler.GetCustomResource()
-		controllerStatus := controller.GetStatus()
-
 		// Wait for actual to be ready
 		childStatus := child.Status(resource)
 		readyCondition := meta.FindStatusCondition(childStatus.Conditions, ConditionTypeReady)
@@ -133,12 +173,11 @@ func waitForChildReady[
 			childRef.Message = "the child resource is not ready"
 		}
 
-		changed := controllerStatus.ChildResources.Set(childRef)
-		if changed {
-			err := reconciler.Status().Update(ctx, controller)
-			if err != nil {
-				return ResultInError(errors.Wrap(err, "failed to update status"))
-			}
+		err := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+			return setChildRef(status, childRef)
+		})
+		if err != nil {
+			return ResultInError(err)
 		}
 
 		if readyCondition == nil || readyCondition.Status != metav1.ConditionTrue {
@@ -153,35 +192,188 @@ func handleCreateOrUpdate[
 	ControllerResourceType ControllerResource,
 ](
 	reconciler Reconciler[ControllerResourceType],
+	child GenericChildResource,
+	childRef *ObjectReference,
 	desired client.Object,
 	actual client.Object,
 	requiresCreation bool,
+	revert bool,
 ) func(ctx context.Context, req ctrl.Request) (client.Object, StepResult) {
 	return func(ctx context.Context, req ctrl.Request) (client.Object, StepResult) {
 		if requiresCreation {
-			// Create the actual object with the desired object
-			err := reconciler.Create(ctx, desired)
+			actual = nil
+		} else {
+			if actual.GetDeletionTimestamp() != nil {
+				// The child is created again once it is gone
+				return nil, waitForChildDeletion(ctx, req)
+			}
+
+			if !revert && GetAnnotation(actual, HashAnnotation) == GetAnnotation(desired, HashAnnotation) {
+				return actual, ResultSuccess()
+			}
+
+			fields, err := changedImmutableFields(actual, desired, child.ImmutableFields())
 			if err != nil {
-				return nil, ResultInError(fmt.Errorf("failed to create child resource: %w", err))
+				return nil, ResultInError(err)
 			}
+			if len(fields) > 0 {
+				return recreateChild(ctx, req, reconciler, child, childRef, desired, actual, fields)
+			}
+		}
+
+		err := writeChild(ctx, reconciler, child, desired, actual)
+		if fields, immutable := immutableFieldError(err); immutable && actual != nil && child.RecreateOnImmutableError() {
+			return recreateChild(ctx, req, reconciler, child, childRef, desired, actual, fields)
+		}
+		if apierrors.IsInvalid(err) {
+			// The generated child is rejected, retrying cannot succeed until the spec changes
+			return nil, ResultTerminal(NewCategorizedError(ErrorCategoryInvalidSpec, err))
+		}
+		if err != nil {
+			return nil, ResultInError(err)
+		}
+
+		return desired, ResultSuccess()
+	}
+}
+
+// writeChild creates the child, or updates the actual one with the desired object.
+func writeChild[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	child GenericChildResource,
+	desired client.Object,
+	actual client.Object,
+) error {
+	if fieldManager := child.FieldManager(); fieldManager != "" {
+		if err := applyChild(ctx, reconciler, desired, fieldManager); err != nil {
+			return fmt.Errorf("failed to apply child resource: %w", err)
+		}
+		return nil
+	}
 
-			return desired, ResultSuccess()
+	if actual == nil {
+		if err := reconciler.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create child resource: %w", err)
 		}
+		return nil
+	}
 
-		desiredHash := GetAnnotation(desired, HashAnnotation)
-		actualHash := GetAnnotation(actual, HashAnnotation)
+	desired.SetResourceVersion(actual.GetResourceVersion())
+	if err := reconciler.Update(ctx, desired); err != nil {
+		return fmt.Errorf("failed to update child resource: %w", err)
+	}
 
-		if actualHash != desiredHash {
-			// Update the actual object with the desired object
-			desired.SetResourceVersion(actual.GetResourceVersion())
+	return nil
+}
 
-			err := reconciler.Update(ctx, desired)
-			if err != nil {
-				return desired, ResultInError(fmt.Errorf("failed to update child resource: %w", err))
-			}
+// applyChild creates or updates the child with server-side apply, forcing the
+// ownership of the fields set in the desired object. The zero values of the
+// typed object are not sent, they would take the fields over from the other
+// managers, e.g. the replicas of a Deployment scaled by an autoscaler.
+func applyChild[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	desired client.Object,
+	fieldManager string,
+) error {
+	// An apply configuration must carry its type and no server-side metadata
+	gvk, err := apiutil.GVKForObject(desired, reconciler.Scheme())
+	if err != nil {
+		return errors.Wrap(err, "failed to get child resource kind")
+	}
+	desired.GetObjectKind().SetGroupVersionKind(gvk)
+	desired.SetResourceVersion("")
+	desired.SetManagedFields(nil)
+
+	if _, ok := desired.(*unstructured.Unstructured); ok {
+		return reconciler.Patch(ctx, desired, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+	}
+
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return errors.Wrap(err, "failed to convert child resource")
+	}
+	pruneZeroFields(reflect.ValueOf(desired), fields)
+
+	applied := &unstructured.Unstructured{Object: fields}
+	if err := reconciler.Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return err
+	}
+
+	// Give the applied object back to the caller like a typed patch would
+	return errors.Wrap(runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, desired), "failed to convert applied child resource")
+}
+
+// pruneZeroFields removes from the fields of a typed object the struct fields
+// left to their zero value. Pointers, maps and slices set to an empty value are
+// kept, they are set on purpose.
+func pruneZeroFields(value reflect.Value, fields map[string]any) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := range value.NumField() {
+		field, fieldValue := value.Type().Field(i), value.Field(i)
+		if !field.IsExported() {
+			continue
 		}
 
-		return actual, ResultSuccess()
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if options == "inline" || (name == "" && field.Anonymous) {
+			pruneZeroFields(fieldValue, fields)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if fieldValue.IsZero() && fieldValue.Kind() != reflect.Pointer {
+			delete(fields, name)
+			continue
+		}
+		pruneZeroValue(fieldValue, fields[name])
+	}
+}
+
+func pruneZeroValue(value reflect.Value, fields any) {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if fields, ok := fields.(map[string]any); ok {
+			pruneZeroFields(value, fields)
+		}
+	case reflect.Slice:
+		if items, ok := fields.([]any); ok && len(items) == value.Len() {
+			for i := range items {
+				pruneZeroValue(value.Index(i), items[i])
+			}
+		}
+	case reflect.Map:
+		if entries, ok := fields.(map[string]any); ok && value.Type().Key().Kind() == reflect.String {
+			for _, key := range value.MapKeys() {
+				pruneZeroValue(value.MapIndex(key), entries[key.String()])
+			}
+		}
 	}
 }
 
@@ -191,25 +383,12 @@ func handleFinalization[
 	reconciler Reconciler[ControllerResourceType],
 	childRef *ObjectReference,
 	actual client.Object,
-	requiresCreation bool,
 ) func(ctx context.Context, req ctrl.Request) StepResult {
-	controller := reconciler.GetCustomResource()
-	status := controller.GetStatus()
-
 	return func(ctx context.Context, req ctrl.Request) StepResult {
-		if isFinalizing(reconciler) {
-			if requiresCreation {
-				return ResultEarlyReturn()
-			}
-			if err := reconciler.Delete(ctx, actual); err != nil {
-				return ResultInError(errors.Wrap(err, "failed to delete child resource"))
-			}
-			changed := status.ChildResources.Remove(childRef)
-			if changed {
-				err := reconciler.Status().Update(ctx, controller)
-				if err != nil {
-					return ResultInError(errors.Wrap(err, "failed to update status"))
-				}
+		if isFinalizing(ctx, reconciler) {
+			// Delete, orphan or retain the child according to its policy
+			if err := releaseChild(ctx, reconciler, childRef, actual); err != nil {
+				return ResultInError(err)
 			}
 		}
 
@@ -223,11 +402,8 @@ func getDesiredObject[
 	reconciler Reconciler[ControllerResourceType],
 	child GenericChildResource,
 ) func(ctx context.Context, req ctrl.Request) (client.Object, StepResult) {
-	controller := reconciler.GetCustomResource()
-	status := controller.GetStatus()
-
 	return func(ctx context.Context, req ctrl.Request) (client.Object, StepResult) {
-		desired, skip, err := child.Generator(ctx, req)
+		desired, skip, err := generateDesiredObject(ctx, req, reconciler, child)
 		if skip {
 			if desired != nil {
 				childRef, err := EmptyObjectReference(reconciler, desired)
@@ -235,33 +411,54 @@ func getDesiredObject[
 					return nil, ResultInError(errors.Wrap(err, "failed to create child resource ref"))
 				}
 
-				changed := status.ChildResources.Remove(childRef)
-				if changed {
-					err = reconciler.Status().Update(ctx, controller)
-					if err != nil {
-						return nil, ResultInError(errors.Wrap(err, "failed to update status"))
-					}
+				err = UpdateStatus(ctx, reconciler, func(status *Status) bool {
+					removed := status.ChildResources.Remove(childRef)
+					return setDriftedCondition(status) || removed
+				})
+				if err != nil {
+					return nil, ResultInError(err)
 				}
 			}
 			return nil, ResultEarlyReturn()
 		}
 		if err != nil {
-			return nil, ResultInError(errors.Wrap(err, "failed to generate child resource"))
+			return nil, ResultInError(err)
 		}
 
-		err = ctrl.SetControllerReference(controller, desired, reconciler.Scheme())
-		if err != nil {
-			return nil, ResultInError(errors.Wrap(err, "failed to set controller reference"))
-		}
+		return desired, ResultSuccess()
+	}
+}
 
-		// Set the hash annotation
-		hash, err := rxhash.HashStruct(desired)
-		if err != nil {
-			return nil, ResultInError(errors.Wrap(err, "failed to hash child resource"))
-		}
+// generateDesiredObject generates the child and sets its controller reference
+// and its hash annotation. It does not write anything.
+func generateDesiredObject[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	req ctrl.Request,
+	reconciler Reconciler[ControllerResourceType],
+	child GenericChildResource,
+) (desired client.Object, skip bool, err error) {
+	desired, skip, err = child.Generator(ctx, req)
+	if skip {
+		return desired, true, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to generate child resource")
+	}
 
-		SetAnnotation(desired, HashAnnotation, hash)
+	err = ctrl.SetControllerReference(reconciler.GetCustomResource(ctx), desired, reconciler.Scheme())
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to set controller reference")
+	}
 
-		return desired, ResultSuccess()
+	// Set the hash annotation
+	hash, err := rxhash.HashStruct(desired)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to hash child resource")
 	}
+
+	SetAnnotation(desired, HashAnnotation, hash)
+
+	return desired, false, nil
 }
@@ -1,12 +1,26 @@
 package library_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"library"
+	"strings"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestInstanceReflect(t *testing.T) {
@@ -29,3 +43,552 @@ func TestInstanceReflect(t *testing.T) {
 		t.Errorf("app and appI should not be the same")
 	}
 }
+
+func TestServerSideApply(t *testing.T) {
+	app := &appv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+		Spec:       appv1.AppSpec{Command: "sleep 10"},
+	}
+
+	var applied []client.PatchOptions
+	reconciler := newInterceptedTestReconciler(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+
+			options := client.PatchOptions{}
+			options.ApplyOptions(opts)
+			applied = append(applied, options)
+
+			// The fake client does not support apply patches
+			return c.Create(ctx, obj)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				t.Error("the child should not be updated")
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, app)
+
+	child := library.NewChildResource(
+		&corev1.ConfigMap{},
+		library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: reconciler.app.Name, Namespace: reconciler.app.Namespace},
+				Data:       map[string]string{"command": reconciler.app.Spec.Command},
+			}, false, nil
+		}),
+		library.WithServerSideApply[*corev1.ConfigMap]("test-operator"),
+	)
+
+	execute := func() {
+		if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewReconcileChildStep(reconciler, child)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	execute()
+	if len(applied) != 1 {
+		t.Fatalf("expected the child to be applied once, got %d", len(applied))
+	}
+	if applied[0].FieldManager != "test-operator" || applied[0].Force == nil || !*applied[0].Force {
+		t.Errorf("expected a forced apply by test-operator, got %+v", applied[0])
+	}
+
+	// The child is unchanged, nothing is applied
+	execute()
+	if len(applied) != 1 {
+		t.Errorf("expected no apply when the hash is unchanged, got %d", len(applied))
+	}
+}
+
+func TestServerSideApplyForeignFields(t *testing.T) {
+	app := &appv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+		Spec:       appv1.AppSpec{Command: "sleep 10"},
+	}
+
+	var payload map[string]any
+	reconciler := newInterceptedTestReconciler(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != types.ApplyPatchType {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+
+			// The fake client does not support apply patches, the applied fields are merged instead
+			applied, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				t.Fatalf("expected an unstructured apply, got %T", obj)
+			}
+			payload = applied.DeepCopy().Object
+			data, err := json.Marshal(payload)
+			if err != nil {
+				return err
+			}
+			if err := c.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data)); !apierrors.IsNotFound(err) {
+				return err
+			}
+			return c.Create(ctx, obj)
+		},
+	}, app)
+
+	child := library.NewChildResource(
+		&appsv1.Deployment{},
+		library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*appsv1.Deployment, bool, error) {
+			return &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:1", Command: []string{reconciler.app.Spec.Command}}}},
+					},
+				},
+			}, false, nil
+		}),
+		library.WithServerSideApply[*appsv1.Deployment]("test-operator"),
+	)
+
+	execute := func() {
+		if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewReconcileChildStep(reconciler, child)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	execute()
+
+	// An autoscaler owns the replicas
+	var deployment appsv1.Deployment
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &deployment); err != nil {
+		t.Fatal(err)
+	}
+	deployment.Spec.Replicas = library.Opt(int32(5))
+	if err := reconciler.Update(context.Background(), &deployment, client.FieldOwner("autoscaler")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new spec applies the child again
+	reconciler.app.Spec.Command = "sleep 20"
+	if err := reconciler.Update(context.Background(), reconciler.app); err != nil {
+		t.Fatal(err)
+	}
+	execute()
+
+	for _, path := range [][]string{{"spec", "replicas"}, {"spec", "strategy"}, {"status"}, {"metadata", "creationTimestamp"}} {
+		if _, found, _ := unstructured.NestedFieldNoCopy(payload, path...); found {
+			t.Errorf("expected %s not to be applied, got %v", strings.Join(path, "."), payload)
+		}
+	}
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &deployment); err != nil {
+		t.Fatal(err)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 5 {
+		t.Errorf("expected the replicas of the autoscaler to be kept, got %v", deployment.Spec.Replicas)
+	}
+	if command := deployment.Spec.Template.Spec.Containers[0].Command; len(command) != 1 || command[0] != "sleep 20" {
+		t.Errorf("expected the new command to be applied, got %v", command)
+	}
+}
+
+func TestDriftPolicy(t *testing.T) {
+	for _, policy := range []library.DriftPolicy{library.DriftPolicyReport, library.DriftPolicyRevert} {
+		t.Run(string(policy), func(t *testing.T) {
+			app := &appv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+				Spec:       appv1.AppSpec{Command: "sleep 10"},
+			}
+			reconciler := newTestReconciler(t, app)
+
+			child := library.NewChildResource(
+				&corev1.ConfigMap{},
+				library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+					return &corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: reconciler.app.Name, Namespace: reconciler.app.Namespace},
+						Data:       map[string]string{"command": reconciler.app.Spec.Command},
+					}, false, nil
+				}),
+				library.WithDriftPolicy[*corev1.ConfigMap](policy),
+			)
+
+			execute := func() {
+				if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewReconcileChildStep(reconciler, child)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			execute()
+
+			// Edit the child out-of-band, leaving the hash annotation alone
+			var configMap corev1.ConfigMap
+			if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &configMap); err != nil {
+				t.Fatal(err)
+			}
+			configMap.Data["command"] = "sleep 20"
+			if err := reconciler.Update(context.Background(), &configMap); err != nil {
+				t.Fatal(err)
+			}
+
+			execute()
+
+			if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &configMap); err != nil {
+				t.Fatal(err)
+			}
+			ref, _ := reconciler.app.Status.ChildResources.Get("", "ConfigMap", "app")
+			drifted := meta.FindStatusCondition(reconciler.app.Status.Conditions, library.ConditionTypeDrifted)
+
+			switch policy {
+			case library.DriftPolicyReport:
+				if configMap.Data["command"] != "sleep 20" {
+					t.Error("the drift should not be reverted")
+				}
+				if ref == nil || len(ref.DriftedFields) != 1 || ref.DriftedFields[0] != "data.command" {
+					t.Errorf("expected data.command to be recorded as drifted, got %+v", ref)
+				}
+				if drifted == nil || drifted.Status != metav1.ConditionTrue {
+					t.Errorf("expected the Drifted condition to be set, got %+v", drifted)
+				}
+			case library.DriftPolicyRevert:
+				if configMap.Data["command"] != "sleep 10" {
+					t.Errorf("expected the drift to be reverted, got %q", configMap.Data["command"])
+				}
+				if ref == nil || len(ref.DriftedFields) != 1 || ref.DriftedFields[0] != "data.command" {
+					t.Errorf("expected the reverted data.command to be recorded, got %+v", ref)
+				}
+
+				// Nothing drifted since the revert
+				execute()
+				ref, _ = reconciler.app.Status.ChildResources.Get("", "ConfigMap", "app")
+				if ref == nil || len(ref.DriftedFields) != 0 {
+					t.Errorf("expected the drifted fields to be cleared, got %+v", ref)
+				}
+				if drifted := meta.FindStatusCondition(reconciler.app.Status.Conditions, library.ConditionTypeDrifted); drifted != nil {
+					t.Errorf("expected no Drifted condition, got %+v", drifted)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftListEntries(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	reconciler := newTestReconciler(t, app)
+
+	child := library.NewChildResource(
+		&appsv1.Deployment{},
+		library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*appsv1.Deployment, bool, error) {
+			return &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:1"}}},
+					},
+				},
+			}, false, nil
+		}),
+		library.WithDriftPolicy[*appsv1.Deployment](library.DriftPolicyReport),
+	)
+
+	execute := func() []string {
+		if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewReconcileChildStep(reconciler, child)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ref, _ := reconciler.app.Status.ChildResources.Get("apps", "Deployment", "app")
+		if ref == nil {
+			t.Fatal("expected the Deployment to be recorded")
+		}
+		return ref.DriftedFields
+	}
+	edit := func(mutate func(*appsv1.Deployment)) {
+		var deployment appsv1.Deployment
+		if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &deployment); err != nil {
+			t.Fatal(err)
+		}
+		mutate(&deployment)
+		if err := reconciler.Update(context.Background(), &deployment); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	execute()
+
+	// A sidecar injected ahead of the generated container is not a drift
+	edit(func(deployment *appsv1.Deployment) {
+		containers := &deployment.Spec.Template.Spec.Containers
+		*containers = append([]corev1.Container{{Name: "sidecar", Image: "proxy:1"}}, *containers...)
+	})
+	if drifted := execute(); len(drifted) != 0 {
+		t.Errorf("expected no drift for the injected sidecar, got %v", drifted)
+	}
+
+	edit(func(deployment *appsv1.Deployment) {
+		deployment.Spec.Template.Spec.Containers[1].Image = "app:2"
+	})
+	if drifted := execute(); len(drifted) != 1 || drifted[0] != "spec.template.spec.containers[app].image" {
+		t.Errorf("expected the image of the app container to drift, got %v", drifted)
+	}
+}
+
+func TestDeletionPolicy(t *testing.T) {
+	now := metav1.Now()
+	app := &appv1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "app",
+			Namespace:         "default",
+			UID:               "app-uid",
+			Finalizers:        []string{"test.multi.ch/finalizer"},
+			DeletionTimestamp: &now,
+		},
+	}
+	owned := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: appv1.GroupVersion.String(), Kind: "App", Name: "app", UID: "app-uid", Controller: library.Opt(true)},
+			},
+		}}
+	}
+
+	// The child is not generated anymore, it is only recorded in the status
+	app.Status.ChildResources.Set(&library.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "removed", Namespace: "default", DeletionPolicy: library.DeletionPolicyOrphan})
+	app.Status.ChildResources.Set(&library.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "kept", Namespace: "default", DeletionPolicy: library.DeletionPolicyRetain})
+
+	reconciler := &childrenReconciler{testReconciler: newTestReconciler(t, app, owned("deleted"), owned("orphaned"), owned("removed"), owned("retained"), owned("kept"))}
+	reconciler.children = func(ctx context.Context, req ctrl.Request) []library.GenericChildResource {
+		configMap := func(name string) library.ChildGenerator[*corev1.ConfigMap] {
+			return func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+				return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: req.Namespace}}, false, nil
+			}
+		}
+
+		return []library.GenericChildResource{
+			library.NewChildResource(&corev1.ConfigMap{}, library.WithChildGenerator(configMap("deleted"))),
+			library.NewChildResource(&corev1.ConfigMap{},
+				library.WithChildGenerator(configMap("orphaned")),
+				library.WithDeletionPolicy[*corev1.ConfigMap](library.DeletionPolicyOrphan),
+			),
+			library.NewChildResource(&corev1.ConfigMap{},
+				library.WithChildGenerator(configMap("retained")),
+				library.WithDeletionPolicy[*corev1.ConfigMap](library.DeletionPolicyRetain),
+			),
+		}
+	}
+
+	_, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)},
+		library.NewReconcileChildrenStep(reconciler),
+		library.NewEndStep(reconciler),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var configMap corev1.ConfigMap
+	err = reconciler.Get(context.Background(), client.ObjectKey{Name: "deleted", Namespace: "default"}, &configMap)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the child to be deleted, got %v", err)
+	}
+
+	// The retained children outlive their parent as well
+	for _, name := range []string{"orphaned", "removed", "retained", "kept"} {
+		if err := reconciler.Get(context.Background(), client.ObjectKey{Name: name, Namespace: "default"}, &configMap); err != nil {
+			t.Fatalf("expected the %s child to be left behind, got %v", name, err)
+		}
+		if len(configMap.OwnerReferences) != 0 {
+			t.Errorf("expected the owner reference of the %s child to be removed, got %+v", name, configMap.OwnerReferences)
+		}
+		if _, ok := reconciler.app.Status.OrphanedResources.Get("", "ConfigMap", name); !ok {
+			t.Errorf("expected the %s child to be recorded as orphaned, got %+v", name, reconciler.app.Status.OrphanedResources)
+		}
+	}
+}
+
+func TestRetainedChild(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	app.Status.ChildResources.Set(&library.ObjectReference{APIVersion: "v1", Kind: "ConfigMap", Name: "retained", Namespace: "default", DeletionPolicy: library.DeletionPolicyRetain})
+	retained := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      "retained",
+		Namespace: "default",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: appv1.GroupVersion.String(), Kind: "App", Name: "app", UID: "app-uid", Controller: library.Opt(true)},
+		},
+	}}
+
+	// The child is not generated anymore
+	reconciler := &childrenReconciler{testReconciler: newTestReconciler(t, app, retained)}
+	reconciler.children = func(ctx context.Context, req ctrl.Request) []library.GenericChildResource {
+		return nil
+	}
+
+	if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewReconcileChildrenStep(reconciler)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var configMap corev1.ConfigMap
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(retained), &configMap); err != nil {
+		t.Fatalf("expected the child to be kept, got %v", err)
+	}
+	if len(configMap.OwnerReferences) != 1 {
+		t.Errorf("expected the child to stay owned until the parent is deleted, got %+v", configMap.OwnerReferences)
+	}
+	if _, ok := reconciler.app.Status.ChildResources.Get("", "ConfigMap", "retained"); !ok {
+		t.Error("expected the child to stay recorded until the parent is deleted")
+	}
+}
+
+func TestRecreateChild(t *testing.T) {
+	immutable := apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "app", field.ErrorList{
+		field.Invalid(field.NewPath("data", "command"), "sleep 20", "field is immutable"),
+	})
+
+	rejectUpdate := func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+		if _, ok := obj.(*corev1.ConfigMap); ok {
+			return immutable
+		}
+		return c.Update(ctx, obj, opts...)
+	}
+
+	cases := map[string]struct {
+		options   []library.ChildResourceOption[*corev1.ConfigMap]
+		update    func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error
+		recreated bool
+	}{
+		"declared": {
+			options: []library.ChildResourceOption[*corev1.ConfigMap]{
+				library.WithImmutableFields[*corev1.ConfigMap]("data"),
+			},
+			recreated: true,
+		},
+		"detected": {
+			options: []library.ChildResourceOption[*corev1.ConfigMap]{
+				library.WithRecreateOnImmutableError[*corev1.ConfigMap](true),
+			},
+			update:    rejectUpdate,
+			recreated: true,
+		},
+		"not detected": {
+			update: rejectUpdate,
+		},
+		"retained": {
+			options: []library.ChildResourceOption[*corev1.ConfigMap]{
+				library.WithImmutableFields[*corev1.ConfigMap]("data"),
+				library.WithDeletionPolicy[*corev1.ConfigMap](library.DeletionPolicyRetain),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			app := &appv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"},
+				Spec:       appv1.AppSpec{Command: "sleep 20"},
+			}
+			existing := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "app",
+					Namespace:   "default",
+					Annotations: map[string]string{library.HashAnnotation: "outdated"},
+				},
+				Data: map[string]string{"command": "sleep 10"},
+			}
+
+			deletes := 0
+			reconciler := newInterceptedTestReconciler(t, interceptor.Funcs{
+				Update: tc.update,
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					deletes++
+					return c.Delete(ctx, obj, opts...)
+				},
+			}, app, existing)
+
+			options := append(tc.options, library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+				return &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: reconciler.app.Name, Namespace: reconciler.app.Namespace},
+					Data:       map[string]string{"command": reconciler.app.Spec.Command},
+				}, false, nil
+			}))
+			child := library.NewChildResource(&corev1.ConfigMap{}, options...)
+
+			_, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, library.NewReconcileChildStep(reconciler, child))
+
+			if !tc.recreated {
+				// The child is left as is until the spec changes
+				if !library.IsTerminal(err) || library.ErrorCategoryOf(err) != library.ErrorCategoryInvalidSpec {
+					t.Errorf("expected a terminal invalid spec error, got %v", err)
+				}
+				if deletes != 0 {
+					t.Errorf("expected the child to be kept, got %d deletes", deletes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if deletes != 1 {
+				t.Errorf("expected the child to be deleted once, got %d", deletes)
+			}
+
+			var configMap corev1.ConfigMap
+			if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(existing), &configMap); err != nil {
+				t.Fatal(err)
+			}
+			if configMap.Data["command"] != "sleep 20" {
+				t.Errorf("expected the child to be recreated with the desired data, got %q", configMap.Data["command"])
+			}
+
+			ref, _ := reconciler.app.Status.ChildResources.Get("", "ConfigMap", "app")
+			if ref == nil || ref.RecreatedAt == nil || len(ref.RecreatedFields) != 1 || ref.RecreatedFields[0] != "data.command" {
+				t.Errorf("expected the recreation to be recorded, got %+v", ref)
+			}
+		})
+	}
+}
+
+func TestUntypedChildResource(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	reconciler := newTestReconciler(t, app)
+
+	var output unstructured.Unstructured
+	child := library.NewUntypedChildResource(
+		schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		library.WithChildOutput(&output),
+		library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*unstructured.Unstructured, bool, error) {
+			obj := &unstructured.Unstructured{Object: map[string]any{"data": map[string]any{"command": "sleep 10"}}}
+			obj.SetName(reconciler.app.Name)
+			obj.SetNamespace(reconciler.app.Namespace)
+			return obj, false, nil
+		}),
+	)
+
+	step := library.NewReconcileChildStep(reconciler, child)
+	if step.Name != "ReconcileChildConfigMap" {
+		t.Errorf("expected the step to be named after the kind, got %s", step.Name)
+	}
+
+	execute := func() corev1.ConfigMap {
+		if _, err := executeSteps(reconciler, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}, step); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var configMap corev1.ConfigMap
+		if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &configMap); err != nil {
+			t.Fatal(err)
+		}
+		return configMap
+	}
+
+	created := execute()
+	configMap := execute()
+	if configMap.ResourceVersion != created.ResourceVersion {
+		t.Error("the unchanged child should not be updated")
+	}
+	if configMap.Data["command"] != "sleep 10" || !metav1.IsControlledBy(&configMap, app) {
+		t.Errorf("expected the child to be created and controlled by the app, got %+v", configMap)
+	}
+	if output.GetUID() != configMap.UID {
+		t.Error("expected the output to be set")
+	}
+	if ref, ok := reconciler.app.Status.ChildResources.Get("", "ConfigMap", "app"); !ok || ref.Status != metav1.ConditionTrue {
+		t.Errorf("expected the child to be recorded as ready, got %+v", ref)
+	}
+}
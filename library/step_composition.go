@@ -0,0 +1,112 @@
+package library
+
+import (
+	"context"
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// StepPredicate decides whether a step should be executed.
+type StepPredicate func(ctx context.Context, req ctrl.Request) bool
+
+// NewStepIf wraps the step so that it is only executed when the predicate holds.
+// When the predicate does not hold, the step succeeds without doing anything.
+func NewStepIf(predicate StepPredicate, step Step) Step {
+	return Step{
+		Name: step.Name,
+		Step: func(ctx context.Context, req ctrl.Request) StepResult {
+			if !predicate(ctx, req) {
+				return ResultSuccess()
+			}
+
+			return step.Step(ctx, req)
+		},
+	}
+}
+
+// WithStepIf adds a step that is only executed when the predicate holds.
+func WithStepIf(predicate StepPredicate, step Step) StepperOptions {
+	return WithStep(NewStepIf(predicate, step))
+}
+
+// NewStepGroup creates a named step executing the steps given as options in order.
+// Groups can be nested, the group returns as soon as one of its steps should return.
+// The interceptors and the backoff of the group apply to its steps, within the ones
+// of the stepper. WithControllerName and WithReconciler only apply to a stepper, the
+// group panics when they are given.
+func NewStepGroup(name string, opts ...StepperOptions) Step {
+	group := &Stepper{}
+	for _, opt := range opts {
+		opt(group)
+	}
+	if group.name != "" || group.client != nil {
+		panic(fmt.Sprintf("library: the step group %s only accepts the options of its steps, its interceptors and its backoff", name))
+	}
+
+	return Step{
+		Name: name,
+		Step: func(ctx context.Context, req ctrl.Request) StepResult {
+			if group.backoff != (Backoff{}) {
+				ctx = context.WithValue(ctx, backoffContextKey{}, group.backoff)
+			}
+
+			for _, step := range group.steps {
+				step.Step = intercept(group.interceptors, step)
+				result, _ := runStep(ctx, req, step)
+				if result.ShouldReturn() {
+					return result
+				}
+			}
+
+			return ResultSuccess()
+		},
+	}
+}
+
+// WithStepGroup adds a named group of steps.
+func WithStepGroup(name string, opts ...StepperOptions) StepperOptions {
+	return WithStep(NewStepGroup(name, opts...))
+}
+
+// WhenFinalizing holds while the controller resource is being deleted.
+func WhenFinalizing[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+) StepPredicate {
+	return func(ctx context.Context, req ctrl.Request) bool {
+		return isFinalizing(ctx, reconciler)
+	}
+}
+
+// WhenNotFinalizing holds while the controller resource is not being deleted.
+func WhenNotFinalizing[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+) StepPredicate {
+	return func(ctx context.Context, req ctrl.Request) bool {
+		return !isFinalizing(ctx, reconciler)
+	}
+}
+
+// WithStepOnFinalization adds a step that only runs during the finalization of the controller resource.
+func WithStepOnFinalization[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+	step Step,
+) StepperOptions {
+	return WithStepIf(WhenFinalizing(reconciler), step)
+}
+
+// WithStepOutsideFinalization adds a step that never runs during the finalization of the controller resource.
+func WithStepOutsideFinalization[
+	ControllerResourceType ControllerResource,
+](
+	reconciler Reconciler[ControllerResourceType],
+	step Step,
+) StepperOptions {
+	return WithStepIf(WhenNotFinalizing(reconciler), step)
+}
@@ -0,0 +1,90 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestStepComposition(t *testing.T) {
+	var executed []string
+	record := func(name string) library.Step {
+		return library.NewStep(name, func(ctx context.Context, req ctrl.Request) library.StepResult {
+			executed = append(executed, name)
+			return library.ResultSuccess()
+		})
+	}
+	always := func(ctx context.Context, req ctrl.Request) bool { return true }
+	never := func(ctx context.Context, req ctrl.Request) bool { return false }
+
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithStep(record("First")),
+		library.WithStepIf(never, record("Skipped")),
+		library.WithStepGroup("Group",
+			library.WithStepIf(always, record("Second")),
+			library.WithStepGroup("Nested",
+				library.WithStep(record("Third")),
+				library.WithStep(library.NewStep("Stop", func(ctx context.Context, req ctrl.Request) library.StepResult {
+					return library.ResultEarlyReturn()
+				})),
+				library.WithStep(record("AfterStop")),
+			),
+			library.WithStep(record("AfterNested")),
+		),
+		library.WithStep(record("Last")),
+	)
+
+	if _, err := stepper.Execute(context.Background(), ctrl.Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"First", "Second", "Third"}
+	if len(executed) != len(expected) {
+		t.Fatalf("expected %v to be executed, got %v", expected, executed)
+	}
+	for i := range expected {
+		if executed[i] != expected[i] {
+			t.Errorf("expected %v to be executed, got %v", expected, executed)
+			break
+		}
+	}
+}
+
+func TestStepGroupOptions(t *testing.T) {
+	var intercepted []string
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithControllerName("test-group-options"),
+		library.WithStepGroup("Group",
+			library.WithInterceptor(func(ctx context.Context, req ctrl.Request, step library.Step, next library.StepFunc) library.StepResult {
+				intercepted = append(intercepted, step.Name)
+				return next(ctx, req)
+			}),
+			library.WithBackoff(time.Hour, time.Hour, 0),
+			library.WithStep(library.NewStep("Wait", func(ctx context.Context, req ctrl.Request) library.StepResult {
+				return library.ResultRequeueWithBackoff(ctx, req)
+			})),
+		),
+	)
+
+	result, err := stepper.Execute(context.Background(), ctrl.Request{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != time.Hour {
+		t.Errorf("expected the backoff of the group, got a requeue after %s", result.RequeueAfter)
+	}
+	if len(intercepted) != 1 || intercepted[0] != "Wait" {
+		t.Errorf("expected the steps of the group to be intercepted, got %v", intercepted)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a group with a controller name to be rejected")
+		}
+	}()
+	library.NewStepGroup("Named", library.WithControllerName("group"))
+}
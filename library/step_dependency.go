@@ -3,10 +3,10 @@ package library
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/pkg/errors"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -21,9 +21,13 @@ func newResolveDependencyStep[
 ) Step {
 	return Step{
 		Name: fmt.Sprintf(StepResolveDependency, dependency.Kind()),
-		Step: func(ctx context.Context, req ctrl.Request) StepResult {
-			controller := reconciler.GetCustomResource()
-			controllerStatus := controller.GetStatus()
+		Step: func(ctx context.Context, req ctrl.Request) (stepResult StepResult) {
+			controller := reconciler.GetCustomResource(ctx)
+
+			// The failures are reset for the dependency once it is resolved
+			defer func() {
+				resetFailures(ctx, req, stepResult)
+			}()
 
 			depKey := dependency.Key()
 			dep := dependency.New()
@@ -34,41 +38,102 @@ func newResolveDependencyStep[
 			if err != nil {
 				return ResultInError(errors.Wrap(err, "failed to create dependency resource ref"))
 			}
+			setSpanObject(ctx, dependencyRef.GroupVersionKind(), dependencyRef.Name, dependencyRef.Namespace)
+			ctx = withFailureTarget(ctx, dependencyRef.GroupVersionKind(), depKey)
+			pendingKey := DependencyKey{GVK: dependencyRef.GroupVersionKind(), NamespacedName: depKey}
+
+			previous := previousDependencyRef(ctx, reconciler, dependencyRef)
+
+			// The watch is set up before the dependency is read from the cache, the
+			// reference it takes keeps the informer of the kind from being released
+			watched, err := watchedDependency(reconciler, dependency, dep)
+			if err != nil {
+				return ResultInError(err)
+			}
+			result := SetupWatch(reconciler, watched, true)(ctx, req)
+			if result.ShouldReturn() {
+				return result.FromSubStep()
+			}
+
+			read, err := readDependency(ctx, reconciler, dependency, depKey, dep)
+			if apierrors.IsNotFound(err) && (dependency.IsOptional() || isLost(previous)) {
+				// The generators see an empty dependency, see IsPresent
+				dependency.Set(newAbsentDependency(dependency))
+
+				dependencyRef.Status = metav1.ConditionFalse
+				if isLost(previous) {
+					// The UID is kept to detect the creation of a replacement
+					dependencyRef.UID = previous.UID
+					dependencyRef.Reason = ReasonLost
+					dependencyRef.Message = "the dependency was deleted"
+					if previous.Reason != ReasonLost {
+						recordWarning(ctx, reconciler, EventReasonDependencyLost, "%s %s was deleted", dependencyRef.Kind, depKey)
+					}
+				} else {
+					dependencyRef.Reason = ReasonAbsent
+					dependencyRef.Message = "the optional dependency does not exist"
+				}
+				statusErr := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+					return status.Dependencies.Set(dependencyRef)
+				})
+				if statusErr != nil {
+					return ResultInError(statusErr)
+				}
 
-			err = reconciler.Get(ctx, depKey, dep)
+				if isFinalizing(ctx, reconciler) {
+					return ResultSuccess()
+				}
+
+				// The reconciliation goes on without the dependency, until it is recreated
+				return waitForDependency(reconciler, pendingKey, watched)(ctx, req)
+			}
 			if err != nil {
 				dependencyRef.ObservedGeneration = controller.GetGeneration()
 				dependencyRef.Status = metav1.ConditionFalse
 				dependencyRef.Reason = ReasonNotFound
 				dependencyRef.Message = err.Error()
 
-				changed := controllerStatus.Dependencies.Set(dependencyRef)
-				if changed {
-					if err := reconciler.Status().Update(ctx, controller); err != nil {
-						return ResultInError(errors.Wrap(err, "failed to update status"))
-					}
+				statusErr := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+					return status.Dependencies.Set(dependencyRef)
+				})
+				if statusErr != nil {
+					return ResultInError(statusErr)
 				}
 
 				if client.IgnoreNotFound(err) != nil {
 					return ResultInError(errors.Wrap(err, "failed to get dependency resource"))
 				}
 
-				if isFinalizing(reconciler) {
+				if isFinalizing(ctx, reconciler) {
 					return ResultSuccess()
 				}
 
-				return ResultRequeueIn(30 * time.Second)
+				return ResultRequeueWithBackoff(ctx, req).WithCause(ErrorCategoryDependencyMissing, err)
 			}
 
 			dependency.Set(dep)
 
-			if isFinalizing(reconciler) {
-				changed, err := RemoveManagedBy(dep, controller, reconciler.GetScheme())
+			dependencyRef.UID = string(dep.GetUID())
+			// A replacement stays marked as such until it is ready
+			var replaced string
+			if isReplaced(previous, dependencyRef.UID) {
+				recordWarning(ctx, reconciler, EventReasonDependencyReplaced, "%s %s was replaced", dependencyRef.Kind, depKey)
+				replaced = fmt.Sprintf("the dependency was recreated, its UID changed from %s", previous.UID)
+			} else if previous != nil && previous.Reason == ReasonReplaced && previous.Status != metav1.ConditionTrue {
+				replaced = previous.Message
+			}
+
+			if dependency.IsOptional() || isLost(previous) {
+				reconciler.RemovePendingDependency(pendingKey, req)
+			}
+
+			if isFinalizing(ctx, reconciler) {
+				changed, err := RemoveManagedBy(read, controller, reconciler.Scheme())
 				if err != nil {
 					return ResultInError(err)
 				}
 				if changed {
-					if err := reconciler.Update(ctx, dep); err != nil {
+					if err := writeManagedBy(ctx, reconciler, read); err != nil {
 						return ResultInError(err)
 					}
 				}
@@ -76,24 +141,31 @@ func newResolveDependencyStep[
 				return ResultSuccess()
 			}
 
-			// Setup watch if not already set
-			result := SetupWatch(reconciler, dep, true)(ctx, req)
-			if result.ShouldReturn() {
-				return result.FromSubStep()
-			}
-
-			changed, err := AddManagedBy(dep, controller, reconciler.GetScheme())
+			changed, err := AddManagedBy(read, controller, reconciler.Scheme())
 			if err != nil {
 				return ResultInError(err)
 			}
 			if changed {
-				if err := reconciler.Update(ctx, dep); err != nil {
+				// The events of the dependency are mapped with the index of the
+				// dependencies in the status, it is written before the dependency
+				// can report a change made after this reconciliation read it
+				err := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+					return status.Dependencies.Set(dependencyRef)
+				})
+				if err != nil {
+					return ResultInError(err)
+				}
+				if err := flushStatus(ctx, reconciler); err != nil {
+					return ResultInError(err)
+				}
+
+				if err := writeManagedBy(ctx, reconciler, read); err != nil {
 					return ResultInError(err)
 				}
 			}
 
 			if dependency.ShouldWaitForReady() {
-				result := waitForDependencyReady(reconciler, dependency, dependencyRef, dep)(ctx, req)
+				result := waitForDependencyReady(reconciler, dependency, dependencyRef, dep, replaced)(ctx, req)
 				if result.ShouldReturn() {
 					return result
 				}
@@ -102,12 +174,16 @@ func newResolveDependencyStep[
 			dependencyRef.Status = metav1.ConditionTrue
 			dependencyRef.Reason = ""
 			dependencyRef.Message = ""
+			if replaced != "" {
+				dependencyRef.Reason = ReasonReplaced
+				dependencyRef.Message = replaced
+			}
 			dependencyRef.ObservedGeneration = controller.GetGeneration()
-			changed = controllerStatus.Dependencies.Set(dependencyRef)
-			if changed {
-				if err := reconciler.Status().Update(ctx, controller); err != nil {
-					return ResultInError(errors.Wrap(err, "failed to update status"))
-				}
+			err = UpdateStatus(ctx, reconciler, func(status *Status) bool {
+				return status.Dependencies.Set(dependencyRef)
+			})
+			if err != nil {
+				return ResultInError(err)
 			}
 
 			return ResultSuccess()
@@ -122,11 +198,9 @@ func waitForDependencyReady[
 	dependency GenericDependencyResource,
 	dependencyRef *ObjectReference,
 	resource client.Object,
+	replaced string,
 ) func(ctx context.Context, req ctrl.Request) StepResult {
 	return func(ctx context.Context, req ctrl.Request) StepResult {
-		controller := reconciler.GetCustomResource()
-		controllerStatus := controller.GetStatus()
-
 		// Wait for actual to be ready
 		dependencyStatus := dependency.Status(resource)
 		readyCondition := meta.FindStatusCondition(dependencyStatus.Conditions, ConditionTypeReady)
@@ -145,13 +219,16 @@ func waitForDependencyReady[
 			dependencyRef.Reason = ReasonUnknown
 			dependencyRef.Message = "the dependency resource is not ready"
 		}
+		if replaced != "" && dependencyRef.Status != metav1.ConditionTrue {
+			dependencyRef.Reason = ReasonReplaced
+			dependencyRef.Message = replaced
+		}
 
-		changed := controllerStatus.Dependencies.Set(dependencyRef)
-		if changed {
-			err := reconciler.Status().Update(ctx, controller)
-			if err != nil {
-				return ResultInError(errors.Wrap(err, "failed to update status"))
-			}
+		err := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+			return status.Dependencies.Set(dependencyRef)
+		})
+		if err != nil {
+			return ResultInError(err)
 		}
 
 		if readyCondition == nil || readyCondition.Status != metav1.ConditionTrue {
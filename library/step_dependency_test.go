@@ -0,0 +1,492 @@
+package library_test
+
+import (
+	"context"
+	"fmt"
+	"library"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestOptionalDependency(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	settings := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+	pending := library.DependencyKey{
+		GVK:            schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		NamespacedName: client.ObjectKeyFromObject(settings),
+	}
+
+	reconciler := newDependencyReconciler(t, []client.Object{app}, library.WithOptional[*corev1.ConfigMap](true))
+
+	execute := func() bool {
+		present := false
+		result, err := reconciler.resolve(req, library.NewStep("Generate", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			present = library.IsPresent(&reconciler.configMap)
+			return library.ResultSuccess()
+		}))
+		if err != nil || !result.IsZero() {
+			t.Fatalf("expected the reconciliation to complete, got %+v, %v", result, err)
+		}
+		return present
+	}
+
+	if execute() {
+		t.Error("the absent dependency should not be present")
+	}
+	ref, _ := reconciler.app.Status.Dependencies.Get("", "ConfigMap", "settings")
+	if ref == nil || ref.Reason != library.ReasonAbsent {
+		t.Errorf("expected the dependency to be recorded as absent, got %+v", ref)
+	}
+	if requests := reconciler.PendingRequests(pending); len(requests) != 1 || requests[0] != req {
+		t.Errorf("expected the request to wait for the dependency, got %+v", requests)
+	}
+
+	if err := reconciler.Create(context.Background(), settings); err != nil {
+		t.Fatal(err)
+	}
+
+	if !execute() {
+		t.Error("the created dependency should be present")
+	}
+	if requests := reconciler.PendingRequests(pending); len(requests) != 0 {
+		t.Errorf("expected the request to stop waiting for the dependency, got %+v", requests)
+	}
+
+	// The absent dependency is removed from the spec
+	if err := reconciler.Delete(context.Background(), settings); err != nil {
+		t.Fatal(err)
+	}
+	execute()
+	if requests := reconciler.PendingRequests(pending); len(requests) != 1 {
+		t.Fatalf("expected the request to wait for the deleted dependency, got %+v", requests)
+	}
+	reconciler.names = nil
+	execute()
+	if requests := reconciler.PendingRequests(pending); len(requests) != 0 {
+		t.Errorf("expected the request to stop waiting for the removed dependency, got %+v", requests)
+	}
+	if len(reconciler.app.Status.Dependencies) != 0 {
+		t.Errorf("expected the removed dependency to be forgotten, got %+v", reconciler.app.Status.Dependencies)
+	}
+}
+
+func TestSelectorDependency(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+	var objs []client.Object
+	for name, tier := range map[string]string{"a": "frontend", "b": "frontend", "c": "backend"} {
+		objs = append(objs, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"tier": tier},
+		}})
+	}
+
+	reconciler := newDependencyReconciler(t, append(objs, app))
+	reconciler.selector = labels.SelectorFromSet(labels.Set{"tier": "frontend"})
+
+	execute := func() []string {
+		result, err := reconciler.resolve(req)
+		if err != nil || !result.IsZero() {
+			t.Fatalf("expected the reconciliation to complete, got %+v, %v", result, err)
+		}
+
+		var names []string
+		for _, item := range reconciler.matches.Items() {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+	isManaged := func(name string) bool {
+		var configMap corev1.ConfigMap
+		if err := reconciler.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, &configMap); err != nil {
+			t.Fatal(err)
+		}
+		managedBy, err := library.GetManagedBy(&configMap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(managedBy) > 0
+	}
+
+	if names := execute(); !slices.Equal(names, []string{"a", "b"}) {
+		t.Fatalf("expected the frontends to be resolved, got %v", names)
+	}
+	if len(reconciler.app.Status.Dependencies) != 2 || !isManaged("a") || !isManaged("b") || isManaged("c") {
+		t.Errorf("expected the frontends to be tracked, got %+v", reconciler.app.Status.Dependencies)
+	}
+
+	created := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "d", Namespace: "default", Labels: map[string]string{"tier": "frontend"}}}
+	if requests := reconciler.SelectorRequests(gvk, created); len(requests) != 1 || requests[0] != req {
+		t.Errorf("expected a new frontend to reconcile the app, got %+v", requests)
+	}
+
+	// b stops matching
+	var b corev1.ConfigMap
+	if err := reconciler.Get(context.Background(), types.NamespacedName{Name: "b", Namespace: "default"}, &b); err != nil {
+		t.Fatal(err)
+	}
+	b.Labels["tier"] = "backend"
+	if err := reconciler.Update(context.Background(), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := execute(); !slices.Equal(names, []string{"a"}) {
+		t.Fatalf("expected a single frontend, got %v", names)
+	}
+	if ref, _ := reconciler.app.Status.Dependencies.Get("", "ConfigMap", "b"); ref != nil || isManaged("b") {
+		t.Errorf("expected b to be released, got %+v", reconciler.app.Status.Dependencies)
+	}
+}
+
+func TestLostDependency(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	settings := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", UID: "settings-uid"}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	reconciler := newDependencyReconciler(t, []client.Object{app, settings})
+
+	present := false
+	execute := func() (*library.ObjectReference, ctrl.Result) {
+		present = false
+		result, err := reconciler.resolve(req, library.NewStep("Generate", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			present = library.IsPresent(&reconciler.configMap)
+			return library.ResultSuccess()
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ref, _ := reconciler.app.Status.Dependencies.Get("", "ConfigMap", "settings")
+		return ref, result
+	}
+	expectEvent := func(reason string) {
+		select {
+		case event := <-reconciler.recorder.Events:
+			if !strings.Contains(event, reason) {
+				t.Errorf("expected a %s event, got %q", reason, event)
+			}
+		default:
+			t.Errorf("expected a %s event", reason)
+		}
+	}
+
+	if ref, result := execute(); ref == nil || ref.UID != "settings-uid" || !present || !result.IsZero() {
+		t.Fatalf("expected the dependency to be resolved, got %+v", ref)
+	}
+
+	if err := reconciler.Delete(context.Background(), settings); err != nil {
+		t.Fatal(err)
+	}
+	// The reconciliation goes on without the required dependency
+	if ref, result := execute(); ref == nil || ref.Reason != library.ReasonLost || present || !result.IsZero() {
+		t.Errorf("expected the dependency to be lost and the reconciliation to complete, got %+v, %+v", ref, result)
+	}
+	expectEvent(library.EventReasonDependencyLost)
+
+	// The event is only recorded once
+	execute()
+	if len(reconciler.recorder.Events) != 0 {
+		t.Errorf("expected no new event, got %q", <-reconciler.recorder.Events)
+	}
+
+	replacement := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", UID: "replacement-uid"}}
+	if err := reconciler.Create(context.Background(), replacement); err != nil {
+		t.Fatal(err)
+	}
+	if ref, result := execute(); ref == nil || ref.Reason != library.ReasonReplaced || ref.UID != "replacement-uid" || !present || !result.IsZero() {
+		t.Errorf("expected the dependency to be replaced, got %+v", ref)
+	}
+	expectEvent(library.EventReasonDependencyReplaced)
+}
+
+func TestReplacedDependencyNotReady(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	settings := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", UID: "settings-uid"},
+		Data:       map[string]string{"ready": "true"},
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	reconciler := newDependencyReconciler(t, []client.Object{app, settings},
+		library.WithWaitForReady[*corev1.ConfigMap](true),
+		library.WithDependencyStatusGetter(func(configMap *corev1.ConfigMap) *library.Status {
+			status := metav1.ConditionFalse
+			if configMap.Data["ready"] == "true" {
+				status = metav1.ConditionTrue
+			}
+			return &library.Status{Conditions: []metav1.Condition{{Type: library.ConditionTypeReady, Status: status, Reason: "Test"}}}
+		}),
+	)
+
+	execute := func() *library.ObjectReference {
+		if _, err := reconciler.resolve(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ref, _ := reconciler.app.Status.Dependencies.Get("", "ConfigMap", "settings")
+		return ref
+	}
+
+	execute()
+	if err := reconciler.Delete(context.Background(), settings); err != nil {
+		t.Fatal(err)
+	}
+	execute()
+
+	replacement := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", UID: "replacement-uid"},
+		Data:       map[string]string{"ready": "false"},
+	}
+	if err := reconciler.Create(context.Background(), replacement); err != nil {
+		t.Fatal(err)
+	}
+
+	// The replacement is marked as such while it is not ready
+	for range 2 {
+		if ref := execute(); ref == nil || ref.Reason != library.ReasonReplaced || ref.UID != "replacement-uid" || ref.Status != metav1.ConditionFalse {
+			t.Errorf("expected the dependency to be replaced and not ready, got %+v", ref)
+		}
+	}
+
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(replacement), replacement); err != nil {
+		t.Fatal(err)
+	}
+	replacement.Data["ready"] = "true"
+	if err := reconciler.Update(context.Background(), replacement); err != nil {
+		t.Fatal(err)
+	}
+	if ref := execute(); ref == nil || ref.Status != metav1.ConditionTrue {
+		t.Errorf("expected the replacement to be ready, got %+v", ref)
+	}
+	if ref := execute(); ref == nil || ref.Reason != "" {
+		t.Errorf("expected the replacement to be cleared once ready, got %+v", ref)
+	}
+}
+
+func TestDependencyRecordedBeforeAnnotation(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	settings := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", UID: "settings-uid"}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	annotations := 0
+	reconciler := &dependencyReconciler{names: []string{"settings"}}
+	reconciler.testReconciler = newInterceptedTestReconciler(t, interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				annotations++
+
+				// The dependents index must already know about the app
+				var current appv1.App
+				if err := c.Get(ctx, req.NamespacedName, &current); err != nil {
+					return err
+				}
+				if _, ok := current.Status.Dependencies.Get("", "ConfigMap", "settings"); !ok {
+					t.Errorf("expected the dependency to be written to the status before the annotation, got %+v", current.Status.Dependencies)
+				}
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, app, settings)
+
+	if _, err := reconciler.resolve(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if annotations != 1 {
+		t.Errorf("expected the dependency to be annotated once, got %d", annotations)
+	}
+}
+
+func TestMetadataOnlyDependency(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	settings := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"},
+		Data:       map[string]string{"mode": "debug"},
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	var reads []string
+	reconciler := &dependencyReconciler{
+		testReconciler: newInterceptedTestReconciler(t, interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if key.Name == "settings" {
+					reads = append(reads, fmt.Sprintf("%T", obj))
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}, app, settings),
+		names:   []string{"settings"},
+		options: []library.DependencyResourceOption[*corev1.ConfigMap]{library.WithMetadataOnly[*corev1.ConfigMap](true)},
+	}
+
+	execute := func() {
+		reads = nil
+		if _, err := reconciler.resolve(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the metadata is read, from the cache
+	execute()
+	if len(reads) != 1 || reads[0] != "*v1.PartialObjectMetadata" {
+		t.Errorf("expected the metadata only to be read, got %v", reads)
+	}
+	if reconciler.configMap.Name != "settings" || len(reconciler.configMap.Data) != 0 {
+		t.Errorf("expected the metadata of the dependency, got %+v", reconciler.configMap)
+	}
+	var annotated corev1.ConfigMap
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(settings), &annotated); err != nil {
+		t.Fatal(err)
+	}
+	if dependents, _ := library.GetDependents(&annotated, appv1.GroupVersion.WithKind("App")); len(dependents) != 1 {
+		t.Errorf("expected the dependency to be annotated as managed by the app, got %v", annotated.Annotations)
+	}
+
+	// The readiness needs the whole object
+	reconciler.options = append(reconciler.options,
+		library.WithWaitForReady[*corev1.ConfigMap](true),
+		library.WithDependencyStatusGetter(func(*corev1.ConfigMap) *library.Status {
+			return &library.Status{Conditions: []metav1.Condition{{Type: library.ConditionTypeReady, Status: metav1.ConditionTrue, Reason: "Test"}}}
+		}),
+	)
+	execute()
+	if len(reads) != 2 || reads[1] != "*v1.ConfigMap" {
+		t.Errorf("expected the whole dependency to be read after its metadata, got %v", reads)
+	}
+	if reconciler.configMap.Data["mode"] != "debug" {
+		t.Errorf("expected the whole dependency to be read, got %+v", reconciler.configMap.Data)
+	}
+
+	key := library.NewWatchKey(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, library.CacheTypeEnqueueForManagedBy)
+	if !reconciler.IsWatchingSource(key) {
+		t.Fatal("expected the dependency kind to be watched")
+	}
+
+	var current appv1.App
+	if err := reconciler.Get(context.Background(), req.NamespacedName, &current); err != nil {
+		t.Fatal(err)
+	}
+	current.Finalizers = nil
+	if err := reconciler.Update(context.Background(), &current); err != nil {
+		t.Fatal(err)
+	}
+	if err := reconciler.Delete(context.Background(), &current); err != nil {
+		t.Fatal(err)
+	}
+
+	// The released informer is the one of the metadata
+	execute()
+	if len(reconciler.cache.removed) != 1 {
+		t.Fatalf("expected the informer to be removed, got %d", len(reconciler.cache.removed))
+	}
+	if _, ok := reconciler.cache.removed[0].(*metav1.PartialObjectMetadata); !ok {
+		t.Errorf("expected the metadata to be watched, got %T", reconciler.cache.removed[0])
+	}
+}
+
+func TestDependencyBackoff(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	settings := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	// The dependencies of the same kind are counted apart
+	reconciler := newDependencyReconciler(t, []client.Object{app, settings})
+	reconciler.names = []string{"missing-a", "missing-b", "settings"}
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		stepper := library.NewStepper(logr.Discard(),
+			library.WithControllerName("test-dependency-backoff"),
+			library.WithBackoff(time.Second, time.Minute, 0),
+			library.WithReconciler(reconciler),
+			library.WithStep(library.NewFindControllerResourceStep(reconciler)),
+			library.WithStep(library.NewResolveDynamicDependenciesStep(reconciler)),
+		)
+		result, err := stepper.Execute(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.RequeueAfter != expected {
+			t.Fatalf("expected a requeue after %s, got %s", expected, result.RequeueAfter)
+		}
+	}
+}
+
+func TestUntypedOptionalDependency(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+	gvk := appv1.GroupVersion.WithKind("App")
+
+	reconciler := &dependenciesReconciler{testReconciler: newTestReconciler(t, app)}
+	reconciler.dependencies = func(ctx context.Context, req ctrl.Request) []library.GenericDependencyResource {
+		return []library.GenericDependencyResource{
+			library.NewUntypedDependencyResource(
+				gvk,
+				library.WithName[*unstructured.Unstructured]("backend"),
+				library.WithNamespace[*unstructured.Unstructured](req.Namespace),
+				library.WithOptional[*unstructured.Unstructured](true),
+			),
+		}
+	}
+
+	if _, err := executeSteps(reconciler, req, library.NewResolveDynamicDependenciesStep(reconciler)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The creation is watched with the kind of the dependency
+	if !reconciler.IsWatchingSource(library.NewWatchKey(gvk, library.CacheTypeEnqueueForPending)) {
+		t.Error("expected the kind of the dependency to be watched")
+	}
+	pending := library.DependencyKey{GVK: gvk, NamespacedName: types.NamespacedName{Name: "backend", Namespace: "default"}}
+	if requests := reconciler.PendingRequests(pending); len(requests) != 1 || requests[0] != req {
+		t.Errorf("expected the request to wait for the dependency, got %+v", requests)
+	}
+}
+
+func TestMetadataOnlySelectorDependency(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	frontend := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", Labels: map[string]string{"tier": "frontend"}},
+		Data:       map[string]string{"mode": "debug"},
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+	var lists []string
+	reconciler := &dependencyReconciler{
+		testReconciler: newInterceptedTestReconciler(t, interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				lists = append(lists, fmt.Sprintf("%T", list))
+				return c.List(ctx, list, opts...)
+			},
+		}, app, frontend),
+		selector: labels.SelectorFromSet(labels.Set{"tier": "frontend"}),
+		options:  []library.DependencyResourceOption[*corev1.ConfigMap]{library.WithMetadataOnly[*corev1.ConfigMap](true)},
+	}
+
+	if _, err := reconciler.resolve(req); err != nil {
+		t.Fatal(err)
+	}
+
+	// The matches are listed from the metadata and only their metadata is read
+	if len(lists) != 1 || lists[0] != "*v1.PartialObjectMetadataList" {
+		t.Errorf("expected the metadata of the matches to be listed, got %v", lists)
+	}
+	if items := reconciler.matches.Items(); len(items) != 1 || items[0].Name != "frontend" || len(items[0].Data) != 0 {
+		t.Errorf("expected the metadata of the match, got %+v", items)
+	}
+}
@@ -14,11 +14,14 @@ func NewReconcileChildrenStep[
 	ControllerResourceType ControllerResource,
 ](
 	reconciler ReconcilerWithDynamicChildren[ControllerResourceType],
+	opts ...StepGraphOption,
 ) Step {
+	config := newStepGraphConfig(opts...)
+
 	return Step{
 		Name: StepReconcileChildren,
 		Step: func(ctx context.Context, req ctrl.Request) StepResult {
-			controller := reconciler.GetCustomResource()
+			controller := reconciler.GetCustomResource(ctx)
 			controllerStatus := controller.GetStatus()
 
 			children, err := reconciler.GetChildren(ctx, req)
@@ -26,35 +29,32 @@ func NewReconcileChildrenStep[
 				return ResultInError(errors.Wrap(err, "failed to get children"))
 			}
 
-			var returnResults []StepResult
-			var newChildrenRefs ObjectReferenceList
-
+			steps := make([]GraphStep, 0, len(children))
 			for _, child := range children {
-				subStep := NewReconcileChildStep(reconciler, child)
-				result := subStep.Step(ctx, req)
-				if result.ShouldReturn() {
-					returnResults = append(returnResults, result)
-					continue
-				}
+				steps = append(steps, NewGraphStep(NewReconcileChildStep(reconciler, child)))
+			}
 
-				output := child.Get()
-				outputRef, err := EmptyObjectReference(reconciler, output)
-				if err != nil {
-					return ResultInError(errors.Wrap(err, "failed to create child resource ref"))
-				}
-				newChildrenRefs.Set(outputRef)
+			// Children do not depend on each other and are reconciled concurrently
+			results, err := runStepGraph(ctx, req, steps, config.maxConcurrency)
+			if err != nil {
+				return ResultInError(errors.Wrap(err, "failed to reconcile children"))
 			}
 
-			// Return result errors first
-			for _, result := range returnResults {
-				if result.err != nil {
-					return result
-				}
+			if result := MergeResults(results...); result.ShouldReturn() {
+				return result
 			}
 
-			for _, result := range returnResults {
-				if result.ShouldReturn() {
-					return result
+			// While finalizing, the generated children were released by their own
+			// steps and the children left in the status are released as well
+			var newChildrenRefs ObjectReferenceList
+			if !isFinalizing(ctx, reconciler) {
+				for _, child := range children {
+					output := child.Get()
+					outputRef, err := EmptyObjectReference(reconciler, output)
+					if err != nil {
+						return ResultInError(errors.Wrap(err, "failed to create child resource ref"))
+					}
+					newChildrenRefs.Set(outputRef)
 				}
 			}
 
@@ -75,18 +75,14 @@ func NewReconcileChildrenStep[
 					}
 				}
 
+				var existing client.Object
 				if err == nil {
-					if err := reconciler.Delete(ctx, &object); err != nil {
-						return ResultInError(err)
-					}
+					existing = &object
 				}
 
-				// Remove the item from the status
-				changed := controllerStatus.ChildResources.Remove(&item)
-				if changed {
-					if err := reconciler.Status().Update(ctx, controller); err != nil {
-						return ResultInError(errors.Wrap(err, "failed to update status"))
-					}
+				// Delete the item according to its policy and remove it from the status
+				if err := releaseChild(ctx, reconciler, &item, existing); err != nil {
+					return ResultInError(err)
 				}
 			}
 
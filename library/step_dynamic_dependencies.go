@@ -8,18 +8,20 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 func NewResolveDynamicDependenciesStep[
 	ControllerResourceType ControllerResource,
 ](
 	reconciler ReconcilerWithDynamicDependencies[ControllerResourceType],
+	opts ...StepGraphOption,
 ) Step {
+	config := newStepGraphConfig(opts...)
+
 	return Step{
 		Name: StepResolveDependencies,
 		Step: func(ctx context.Context, req ctrl.Request) StepResult {
-			controller := reconciler.GetCustomResource()
+			controller := reconciler.GetCustomResource(ctx)
 			controllerStatus := controller.GetStatus()
 
 			dependencies, err := reconciler.GetDependencies(ctx, req)
@@ -27,39 +29,38 @@ func NewResolveDynamicDependenciesStep[
 				return ResultInError(errors.Wrap(err, "failed to get dependencies"))
 			}
 
-			var returnResults []StepResult
-			var newDependenciesRef ObjectReferenceList
+			dependencies, err = resolveSelectorDependencies(ctx, req, reconciler, dependencies, !isFinalizing(ctx, reconciler))
+			if err != nil {
+				return ResultInError(errors.Wrap(err, "failed to resolve selector dependencies"))
+			}
+			if isFinalizing(ctx, reconciler) {
+				reconciler.SetSelectorDependencies(req, nil)
+			}
 
+			steps := make([]GraphStep, 0, len(dependencies))
 			for _, dependency := range dependencies {
-				subStep := newResolveDependencyStep(reconciler, dependency)
-				result := subStep.Step(ctx, req)
-				if result.ShouldReturn() {
-					returnResults = append(returnResults, result)
-					continue
-				}
-
-				if isFinalizing(reconciler) {
-					continue
-				}
+				steps = append(steps, NewGraphStep(newResolveDependencyStep(reconciler, dependency)))
+			}
 
-				output := dependency.Get()
-				outputRef, err := EmptyObjectReference(reconciler, output)
-				if err != nil {
-					return ResultInError(errors.Wrap(err, "failed to create dependency resource ref"))
-				}
-				newDependenciesRef.Set(outputRef)
+			// Dependencies do not depend on each other and are resolved concurrently
+			results, err := runStepGraph(ctx, req, steps, config.maxConcurrency)
+			if err != nil {
+				return ResultInError(errors.Wrap(err, "failed to resolve dependencies"))
 			}
 
-			// Return result errors first
-			for _, result := range returnResults {
-				if result.err != nil {
-					return result
-				}
+			if result := MergeResults(results...); result.ShouldReturn() {
+				return result
 			}
 
-			for _, result := range returnResults {
-				if result.ShouldReturn() {
-					return result
+			var newDependenciesRef ObjectReferenceList
+			if !isFinalizing(ctx, reconciler) {
+				for _, dependency := range dependencies {
+					output := dependency.Get()
+					outputRef, err := EmptyObjectReference(reconciler, output)
+					if err != nil {
+						return ResultInError(errors.Wrap(err, "failed to create dependency resource ref"))
+					}
+					newDependenciesRef.Set(outputRef)
 				}
 			}
 
@@ -73,6 +74,10 @@ func NewResolveDynamicDependenciesStep[
 					Name:      item.Name,
 					Namespace: item.Namespace,
 				}
+
+				// An absent dependency is not waited for anymore
+				reconciler.RemovePendingDependency(DependencyKey{GVK: item.GroupVersionKind(), NamespacedName: key}, req)
+
 				err := reconciler.Get(ctx, key, &object)
 				if err != nil {
 					// Ignore not found errors
@@ -82,17 +87,13 @@ func NewResolveDynamicDependenciesStep[
 				}
 
 				if err == nil {
-					// Remove its owner reference
-					hasOwnerRef, err := controllerutil.HasOwnerReference(object.GetOwnerReferences(), controller, reconciler.GetScheme())
+					// The object is not a dependency anymore
+					changed, err := RemoveManagedBy(&object, controller, reconciler.Scheme())
 					if err != nil {
-						return ResultInError(errors.Wrap(err, "failed to check owner reference"))
+						return ResultInError(errors.Wrap(err, "failed to remove managed-by reference"))
 					}
 
-					if hasOwnerRef {
-						if err := controllerutil.RemoveOwnerReference(controller, &object, reconciler.GetScheme()); err != nil {
-							return ResultInError(errors.Wrap(err, "failed to remove owner reference"))
-						}
-
+					if changed {
 						if err := reconciler.Update(ctx, &object); err != nil {
 							return ResultInError(errors.Wrap(err, "failed to update dependency resource"))
 						}
@@ -100,11 +101,11 @@ func NewResolveDynamicDependenciesStep[
 				}
 
 				// Remove the item from the status
-				changed := controllerStatus.Dependencies.Remove(&item)
-				if changed {
-					if err := reconciler.Status().Update(ctx, controller); err != nil {
-						return ResultInError(errors.Wrap(err, "failed to update status"))
-					}
+				err = UpdateStatus(ctx, reconciler, func(status *Status) bool {
+					return status.Dependencies.Remove(&item)
+				})
+				if err != nil {
+					return ResultInError(err)
 				}
 			}
 
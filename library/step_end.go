@@ -27,26 +27,25 @@ func NewEndStep[
 	return Step{
 		Name: StepEndReconciliation,
 		Step: func(ctx context.Context, req ctrl.Request) StepResult {
-			// Get the controller resource
-			controllerResource := reconciler.GetCustomResource()
-			controllerResourceStatus := controllerResource.GetStatus()
-
-			// Set the ready condition if it doesn't exist
-			readyCondition := &defaultEndReadyCondition
-			readyCondition.ObservedGeneration = controllerResource.GetGeneration()
-			changed := meta.SetStatusCondition(&controllerResourceStatus.Conditions, *readyCondition)
-			if changed {
-				err := reconciler.Status().Update(ctx, controllerResource)
-				if err != nil {
-					return ResultInError(errors.Wrap(err, "failed to update controller resource status"))
-				}
+			// Set the ready condition
+			err := UpdateStatus(ctx, reconciler, func(status *Status) bool {
+				readyCondition := defaultEndReadyCondition
+				readyCondition.ObservedGeneration = reconciler.GetCustomResource(ctx).GetGeneration()
+				return meta.SetStatusCondition(&status.Conditions, readyCondition)
+			})
+			if err != nil {
+				return ResultInError(errors.Wrap(err, "failed to update controller resource status"))
 			}
 
 			// If it's finalizing, remove the finalizer
-			if isFinalizing(reconciler) {
-				changed = controllerutil.RemoveFinalizer(controllerResource, reconciler.GetFinalizer())
+			if isFinalizing(ctx, reconciler) {
+				controllerResource := reconciler.GetCustomResource(ctx)
+				changed := controllerutil.RemoveFinalizer(controllerResource, reconciler.GetFinalizer())
 				if changed {
+					// The response would overwrite the status changes that are not written yet
+					status := *controllerResource.GetStatus()
 					err := reconciler.Update(ctx, controllerResource)
+					*controllerResource.GetStatus() = status
 					if err != nil {
 						return ResultInError(errors.Wrap(err, "failed to update controller resource"))
 					}
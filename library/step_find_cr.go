@@ -30,7 +30,7 @@ func NewFindControllerResourceStep[
 		Name: StepFindControllerResource,
 		Step: func(ctx context.Context, req ctrl.Request) StepResult {
 			// Get the controller resource
-			controllerResource := reconciler.GetCustomResource()
+			controllerResource := reconciler.GetCustomResource(ctx)
 
 			// Get the controller resource from the client
 			err := reconciler.Get(ctx, req.NamespacedName, controllerResource)
@@ -40,7 +40,13 @@ func NewFindControllerResourceStep[
 					return ResultInError(errors.Wrap(err, "failed to get controller resource"))
 				}
 
-				return ResultEarlyReturn()
+				return releaseControllerResource(ctx, reconciler, req)
+			}
+
+			// The finalizer was removed out-of-band, the resource goes away without
+			// finalization and a finalizer can no longer be added to it
+			if controllerResource.GetDeletionTimestamp() != nil && !controllerutil.ContainsFinalizer(controllerResource, reconciler.GetFinalizer()) {
+				return releaseControllerResource(ctx, reconciler, req)
 			}
 
 			// Set the finalizer if not already set
@@ -53,53 +59,63 @@ func NewFindControllerResourceStep[
 			}
 
 			// Set the controller resource in the reconciler
-			reconciler.SetCustomResource(controllerResource)
-
-			controllerResourceStatus := controllerResource.GetStatus()
-
-			// Set the ready condition if it doesn't exist
-			readyCondition, defaulted := controllerResourceStatus.FindOrDefaultCondition(defaultReadyCondition)
-			if defaulted {
-				readyCondition.ObservedGeneration = controllerResource.GetGeneration()
-				meta.SetStatusCondition(&controllerResourceStatus.Conditions, *readyCondition)
-
-				err = reconciler.Status().Update(ctx, controllerResource)
-				if err != nil {
-					return ResultInError(errors.Wrap(err, "failed to update controller resource status"))
-				}
-			} else if readyCondition.ObservedGeneration != controllerResource.GetGeneration() {
-				// If the observed generation is not equal to the current generation, update it
-				readyCondition.ObservedGeneration = controllerResource.GetGeneration()
-				readyCondition.Status = metav1.ConditionFalse
-				readyCondition.Reason = ReasonReconciling
-				readyCondition.Message = "the resource is being reconciled"
-				changed = meta.SetStatusCondition(&controllerResourceStatus.Conditions, *readyCondition)
-				if changed {
-					err = reconciler.Status().Update(ctx, controllerResource)
-					if err != nil {
-						return ResultInError(errors.Wrap(err, "failed to update controller resource status"))
-					}
+			reconciler.SetCustomResource(ctx, controllerResource)
+			snapshotStatus(ctx, reconciler.GetCustomResource(ctx))
+
+			err = UpdateStatus(ctx, reconciler, func(status *Status) bool {
+				controllerResource := reconciler.GetCustomResource(ctx)
+
+				changed := false
+
+				// Set the ready condition if it doesn't exist
+				readyCondition, defaulted := status.FindOrDefaultCondition(defaultReadyCondition)
+				if defaulted {
+					readyCondition.ObservedGeneration = controllerResource.GetGeneration()
+					changed = meta.SetStatusCondition(&status.Conditions, *readyCondition)
+				} else if readyCondition.ObservedGeneration != controllerResource.GetGeneration() {
+					// If the observed generation is not equal to the current generation, update it
+					readyCondition.ObservedGeneration = controllerResource.GetGeneration()
+					readyCondition.Status = metav1.ConditionFalse
+					readyCondition.Reason = ReasonReconciling
+					readyCondition.Message = "the resource is being reconciled"
+					changed = meta.SetStatusCondition(&status.Conditions, *readyCondition)
 				}
 
-			}
-
-			// If it's finalizing, change the ready condition to false and set the reason
-			if isFinalizing(reconciler) {
-				readyCondition.Status = metav1.ConditionFalse
-				readyCondition.Reason = ReasonFinalizing
-				readyCondition.Message = "the resource is being finalized"
-				readyCondition.ObservedGeneration = controllerResource.GetGeneration()
-
-				changed = meta.SetStatusCondition(&controllerResourceStatus.Conditions, *readyCondition)
-				if changed {
-					err = reconciler.Status().Update(ctx, controllerResource)
-					if err != nil {
-						return ResultInError(errors.Wrap(err, "failed to update controller resource status"))
-					}
+				// If it's finalizing, change the ready condition to false and set the reason
+				if isFinalizing(ctx, reconciler) {
+					readyCondition.Status = metav1.ConditionFalse
+					readyCondition.Reason = ReasonFinalizing
+					readyCondition.Message = "the resource is being finalized"
+					readyCondition.ObservedGeneration = controllerResource.GetGeneration()
+					changed = meta.SetStatusCondition(&status.Conditions, *readyCondition) || changed
 				}
+
+				return changed
+			})
+			if err != nil {
+				return ResultInError(errors.Wrap(err, "failed to update controller resource status"))
 			}
 
 			return ResultSuccess()
 		},
 	}
 }
+
+// releaseControllerResource forgets the failures and the watches of a controller
+// resource that is gone, or going away without finalization.
+func releaseControllerResource[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	req ctrl.Request,
+) StepResult {
+	forgetFailures(ctx, req)
+
+	// The kinds watched for the resource only are not watched anymore
+	if err := releaseWatches(ctx, reconciler, req); err != nil {
+		return ResultInError(err)
+	}
+
+	return ResultEarlyReturn()
+}
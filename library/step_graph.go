@@ -0,0 +1,243 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// DefaultMaxConcurrency is the number of steps of a graph executed at the same time.
+	DefaultMaxConcurrency = 4
+)
+
+// GraphStep is a step of a step graph. It is executed once every step it
+// depends on succeeded, and skipped if one of them should return.
+type GraphStep struct {
+	Step
+
+	// DependsOn is the list of the names of the steps that must succeed before this one
+	DependsOn []string
+}
+
+func NewGraphStep(step Step, dependsOn ...string) GraphStep {
+	return GraphStep{
+		Step:      step,
+		DependsOn: dependsOn,
+	}
+}
+
+type stepGraphConfig struct {
+	maxConcurrency int
+}
+
+type StepGraphOption func(*stepGraphConfig)
+
+// WithMaxConcurrency sets the maximum number of steps of a graph executed at the same time.
+func WithMaxConcurrency(maxConcurrency int) StepGraphOption {
+	return func(c *stepGraphConfig) {
+		c.maxConcurrency = maxConcurrency
+	}
+}
+
+func newStepGraphConfig(opts ...StepGraphOption) *stepGraphConfig {
+	config := &stepGraphConfig{
+		maxConcurrency: DefaultMaxConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.maxConcurrency < 1 {
+		config.maxConcurrency = 1
+	}
+
+	return config
+}
+
+// NewStepGraph creates a step executing the graph steps, running the independent
+// ones concurrently. The results are merged with MergeResults.
+func NewStepGraph(name string, steps []GraphStep, opts ...StepGraphOption) Step {
+	config := newStepGraphConfig(opts...)
+
+	return Step{
+		Name: name,
+		Step: func(ctx context.Context, req ctrl.Request) StepResult {
+			results, err := runStepGraph(ctx, req, steps, config.maxConcurrency)
+			if err != nil {
+				return ResultInError(err)
+			}
+
+			return MergeResults(results...)
+		},
+	}
+}
+
+// MergeResults merges the results of steps executed together. The first error
+// is returned first, then the first result that should return.
+func MergeResults(results ...StepResult) StepResult {
+	for _, result := range results {
+		if result.err != nil {
+			return result
+		}
+	}
+
+	for _, result := range results {
+		if result.ShouldReturn() {
+			return result
+		}
+	}
+
+	return ResultSuccess()
+}
+
+// runStepGraph executes the graph and returns the result of every step, in the
+// order of the steps. A skipped step is reported as an early return.
+func runStepGraph(ctx context.Context, req ctrl.Request, steps []GraphStep, maxConcurrency int) ([]StepResult, error) {
+	byName := make(map[string][]int)
+	for i, step := range steps {
+		byName[step.Name] = append(byName[step.Name], i)
+	}
+
+	pending := make([]int, len(steps))
+	dependents := make([][]int, len(steps))
+	for i, step := range steps {
+		for _, dependency := range step.DependsOn {
+			indexes, ok := byName[dependency]
+			if !ok {
+				return nil, fmt.Errorf("step %s depends on unknown step %s", step.Name, dependency)
+			}
+
+			for _, j := range indexes {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	if err := checkStepGraphCycles(steps, pending, dependents); err != nil {
+		return nil, err
+	}
+
+	// Steps can update the status concurrently
+	ctx = withStatusLock(ctx)
+
+	var (
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+		workers = make(chan struct{}, maxConcurrency)
+		results = make([]StepResult, len(steps))
+		blocked = make([]bool, len(steps))
+	)
+
+	var schedule func(i int)
+
+	// complete marks the step as done and returns the steps that became ready.
+	// It must be called with the lock held.
+	var complete func(i int, succeeded bool) []int
+	complete = func(i int, succeeded bool) []int {
+		var ready []int
+		for _, j := range dependents[i] {
+			if !succeeded {
+				blocked[j] = true
+			}
+
+			pending[j]--
+			if pending[j] > 0 {
+				continue
+			}
+
+			if blocked[j] {
+				results[j] = ResultEarlyReturn()
+				ready = append(ready, complete(j, false)...)
+				continue
+			}
+
+			ready = append(ready, j)
+		}
+		return ready
+	}
+
+	schedule = func(i int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := runGraphStep(ctx, req, steps[i].Step, workers)
+
+			lock.Lock()
+			results[i] = result
+			ready := complete(i, !result.ShouldReturn())
+			lock.Unlock()
+
+			for _, j := range ready {
+				schedule(j)
+			}
+		}()
+	}
+
+	var roots []int
+	for i := range steps {
+		if pending[i] == 0 {
+			roots = append(roots, i)
+		}
+	}
+	for _, i := range roots {
+		schedule(i)
+	}
+
+	wg.Wait()
+
+	return results, nil
+}
+
+// runGraphStep executes the step once a worker is available. The step runs in
+// its own goroutine, a panic is not recovered by controller-runtime and is
+// turned into an error result.
+func runGraphStep(ctx context.Context, req ctrl.Request, step Step, workers chan struct{}) (result StepResult) {
+	workers <- struct{}{}
+	defer func() {
+		<-workers
+		if r := recover(); r != nil {
+			result = ResultInError(fmt.Errorf("panic in step %s: %v", step.Name, r))
+		}
+	}()
+
+	result, _ = runStep(ctx, req, step)
+	return result
+}
+
+func checkStepGraphCycles(steps []GraphStep, pending []int, dependents [][]int) error {
+	remaining := make([]int, len(pending))
+	copy(remaining, pending)
+
+	var queue []int
+	for i := range steps {
+		if remaining[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	visited := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited++
+
+		for _, j := range dependents[i] {
+			remaining[j]--
+			if remaining[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	if visited != len(steps) {
+		return fmt.Errorf("the step graph contains a cycle")
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package library_test
+
+import (
+	"context"
+	"errors"
+	"library"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestStepGraph(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		executed []string
+		running  atomic.Int32
+		peak     atomic.Int32
+	)
+	record := func(name string, result library.StepResult) library.Step {
+		return library.NewStep(name, func(ctx context.Context, req ctrl.Request) library.StepResult {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := peak.Load()
+				if current <= previous || peak.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			lock.Lock()
+			executed = append(executed, name)
+			lock.Unlock()
+			return result
+		})
+	}
+
+	step := library.NewStepGraph("Graph", []library.GraphStep{
+		library.NewGraphStep(record("A", library.ResultSuccess())),
+		library.NewGraphStep(record("B", library.ResultSuccess())),
+		library.NewGraphStep(record("C", library.ResultSuccess())),
+		library.NewGraphStep(record("D", library.ResultSuccess()), "A", "B"),
+		library.NewGraphStep(record("Failing", library.ResultInError(errors.New("boom"))), "D"),
+		library.NewGraphStep(record("Skipped", library.ResultSuccess()), "Failing"),
+	}, library.WithMaxConcurrency(2))
+
+	result := step.Step(context.Background(), ctrl.Request{})
+	if _, err := result.Normal(); err == nil {
+		t.Fatal("expected the error of the failing step")
+	}
+
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 steps running at the same time, got %d", peak.Load())
+	}
+
+	position := make(map[string]int)
+	for i, name := range executed {
+		position[name] = i
+	}
+	if _, ok := position["Skipped"]; ok {
+		t.Error("a step depending on a failing step should be skipped")
+	}
+	if len(executed) != 5 {
+		t.Fatalf("expected 5 steps to be executed, got %v", executed)
+	}
+	if position["D"] < position["A"] || position["D"] < position["B"] {
+		t.Errorf("D should run after its dependencies, got %v", executed)
+	}
+}
+
+func TestStepGraphCycle(t *testing.T) {
+	noop := func(ctx context.Context, req ctrl.Request) library.StepResult {
+		return library.ResultSuccess()
+	}
+
+	step := library.NewStepGraph("Graph", []library.GraphStep{
+		library.NewGraphStep(library.NewStep("A", noop), "B"),
+		library.NewGraphStep(library.NewStep("B", noop), "A"),
+	})
+
+	if _, err := step.Step(context.Background(), ctrl.Request{}).Normal(); err == nil {
+		t.Fatal("expected a cycle to be reported")
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	merged := library.MergeResults(
+		library.ResultSuccess(),
+		library.ResultRequeueIn(time.Second),
+		library.ResultInError(errors.New("boom")),
+	)
+	if _, err := merged.Normal(); err == nil {
+		t.Error("errors should take priority over requeues")
+	}
+
+	merged = library.MergeResults(library.ResultSuccess(), library.ResultEarlyReturn())
+	if !merged.ShouldReturn() {
+		t.Error("the merged result should return when one of the results returns")
+	}
+
+	if library.MergeResults(library.ResultSuccess(), library.ResultSuccess()).ShouldReturn() {
+		t.Error("the merged result of successes should not return")
+	}
+}
+
+func TestStepGraphPanic(t *testing.T) {
+	step := library.NewStepGraph("Graph", []library.GraphStep{
+		library.NewGraphStep(library.NewStep("Panicking", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			panic("boom")
+		})),
+		library.NewGraphStep(library.NewStep("Skipped", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			t.Error("a step depending on a panicking step should be skipped")
+			return library.ResultSuccess()
+		}), "Panicking"),
+	}, library.WithMaxConcurrency(1))
+
+	if _, err := step.Step(context.Background(), ctrl.Request{}).Normal(); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected the panic to be returned as an error, got %v", err)
+	}
+}
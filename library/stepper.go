@@ -5,7 +5,12 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // Stepper is a utility to execute a series of steps in a controller.
@@ -16,12 +21,41 @@ import (
 // the execution of multiple steps in a clean and organized manner.
 type Stepper struct {
 	logger logr.Logger
+	name   string
 	steps  []Step
+
+	interceptors []StepInterceptor
+	backoff      Backoff
+
+	client   client.Client
+	resource func(ctx context.Context) ControllerResource
+	release  func(ctx context.Context, req ctrl.Request) error
 }
 
 type StepperOptions func(*Stepper)
 
-// WithLogger sets the logger for the Stepper.
+// WithControllerName sets the controller name used to label the step metrics.
+func WithControllerName(name string) StepperOptions {
+	return func(s *Stepper) {
+		s.name = name
+	}
+}
+
+// WithReconciler binds the Stepper to the controller resource of the reconciler,
+// so that the last step, the progress and the reconcile history are recorded in its status.
+func WithReconciler[ControllerResourceType ControllerResource](reconciler Reconciler[ControllerResourceType]) StepperOptions {
+	return func(s *Stepper) {
+		s.client = reconciler
+		s.resource = func(ctx context.Context) ControllerResource {
+			return reconciler.GetCustomResource(ctx)
+		}
+		s.release = func(ctx context.Context, req ctrl.Request) error {
+			return releaseUnusedWatches(ctx, reconciler, req)
+		}
+	}
+}
+
+// WithStep adds a step, the steps are executed in the order they are added.
 func WithStep(step Step) StepperOptions {
 	return func(s *Stepper) {
 		s.steps = append(s.steps, step)
@@ -30,8 +64,9 @@ func WithStep(step Step) StepperOptions {
 
 func NewStepper(logger logr.Logger, opts ...StepperOptions) *Stepper {
 	stepper := &Stepper{
-		logger: logger,
-		steps:  []Step{},
+		logger:  logger,
+		steps:   []Step{},
+		backoff: DefaultBackoff,
 	}
 
 	for _, opt := range opts {
@@ -46,12 +81,54 @@ type StepResult struct {
 	err          error
 	requeue      bool
 	requeueAfter time.Duration
+
+	// failures is the number of consecutive failures of a result requeued with backoff
+	failures int
+
+	// step is the innermost step that returned this result
+	step string
+	// category and cause explain why a result that is not an error stopped the reconciliation
+	category ErrorCategory
+	cause    error
 }
 
 func (result StepResult) ShouldReturn() bool {
 	return result.err != nil || result.requeue || result.requeueAfter > 0 || result.earlyReturn
 }
 
+// Outcome returns the label used to report the result in the step metrics.
+func (result StepResult) Outcome() string {
+	switch {
+	case result.err != nil:
+		return StepOutcomeError
+	case result.requeue || result.requeueAfter > 0:
+		return StepOutcomeRequeue
+	case result.earlyReturn:
+		return StepOutcomeEarlyReturn
+	default:
+		return StepOutcomeSuccess
+	}
+}
+
+// WithCause explains why the reconciliation stopped without an error,
+// e.g. while waiting for a missing dependency. The Ready condition reports it.
+func (result StepResult) WithCause(category ErrorCategory, cause error) StepResult {
+	result.category = category
+	result.cause = cause
+	return result
+}
+
+// failure returns the category and the error that made the step fail, if any.
+func (result StepResult) failure() (ErrorCategory, error, bool) {
+	if result.err != nil {
+		return ErrorCategoryOf(result.err), result.err, true
+	}
+	if result.category != "" && result.cause != nil {
+		return result.category, result.cause, true
+	}
+	return "", nil, false
+}
+
 func (result StepResult) FromSubStep() StepResult {
 	result.earlyReturn = false
 	return result
@@ -76,6 +153,17 @@ func ResultInError(err error) StepResult {
 	}
 }
 
+// ResultTerminal returns an error that is not retried until the resource changes.
+func ResultTerminal(err error) StepResult {
+	if !IsTerminal(err) {
+		err = reconcile.TerminalError(err)
+	}
+
+	return StepResult{
+		err: err,
+	}
+}
+
 func ResultRequeueIn(result time.Duration) StepResult {
 	return StepResult{
 		requeue:      true,
@@ -114,20 +202,74 @@ func NewStep(name string, step func(ctx context.Context, req ctrl.Request) StepR
 	}
 }
 
+type stepperContextKey struct{}
+
+// runStep executes a step through the interceptors and records its metrics
+// against the stepper driving the current reconciliation, sub-steps included.
+func runStep(ctx context.Context, req ctrl.Request, step Step) (StepResult, time.Duration) {
+	stepper, _ := ctx.Value(stepperContextKey{}).(*Stepper)
+
+	ctx, span := tracer().Start(ctx, step.Name, trace.WithAttributes(
+		attribute.String(AttributeStep, step.Name),
+	))
+	ctx = context.WithValue(ctx, stepNameContextKey{}, step.Name)
+
+	execute := StepFunc(step.Step)
+	if stepper != nil {
+		execute = intercept(stepper.interceptors, step)
+	}
+
+	startedAt := time.Now()
+	result := execute(ctx, req)
+	duration := time.Since(startedAt)
+
+	resetFailures(ctx, req, result)
+	if result.ShouldReturn() && result.step == "" {
+		result.step = step.Name
+	}
+
+	endSpan(span, result)
+	if stepper != nil {
+		observeStep(stepper.name, step.Name, result, duration)
+	}
+
+	return result, duration
+}
+
 func (stepper *Stepper) Execute(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := stepper.logger
+	ctx = context.WithValue(ctx, stepperContextKey{}, stepper)
+
+	// The controller resource and the outputs of the steps are held by the context
+	ctx = WithState(ctx)
+
+	// Status changes are written once, at the end of the execution
+	var batch *statusBatch
+	if stepper.client != nil {
+		ctx, batch = newStatusBatch(ctx)
+	}
+
+	ctx, span := tracer().Start(ctx, "Reconcile", trace.WithAttributes(
+		attribute.String(AttributeController, stepper.name),
+		attribute.String(AttributeName, req.Name),
+		attribute.String(AttributeNamespace, req.Namespace),
+	))
+	defer span.End()
 
 	startedAt := time.Now()
 
 	logger.Info("\n\nStarting stepper execution")
 
+	result := ResultSuccess()
+	lastStep := ""
+	completed := 0
+
 	for _, step := range stepper.steps {
-		// time.Sleep(5 * time.Second)
 		logger.Info("Executing step", "step", step.Name)
 
-		stepStartedAt := time.Now()
-		result := step.Step(ctx, req)
-		stepDuration := time.Since(stepStartedAt)
+		var stepDuration time.Duration
+		lastStep = step.Name
+		result, stepDuration = runStep(ctx, req, step)
 
 		if result.ShouldReturn() {
 			if result.err != nil {
@@ -137,12 +279,36 @@ func (stepper *Stepper) Execute(ctx context.Context, req ctrl.Request) (ctrl.Res
 			} else if result.requeueAfter > 0 {
 				logger.Info("Requeueing after step", "step", step.Name, "after", result.requeueAfter, "stepDuration", stepDuration)
 			}
-			return result.Normal()
+			break
 		}
 
+		completed++
 		logger.Info("Executed step", "step", step.Name, "stepDuration", stepDuration)
 	}
 
-	logger.Info("All steps executed successfully", "duration", time.Since(startedAt))
-	return ctrl.Result{}, nil
+	if !result.ShouldReturn() {
+		logger.Info("All steps executed successfully", "duration", time.Since(startedAt))
+
+		// Every step ran, the watches the request did not use are not needed anymore
+		if stepper.release != nil {
+			if err := stepper.release(ctx, req); err != nil {
+				logger.Error(err, "Failed to release the unused watches")
+				result = ResultInError(err)
+			}
+		}
+	}
+
+	span.SetAttributes(attribute.String(AttributeResult, result.Outcome()))
+	if result.err != nil {
+		span.SetStatus(codes.Error, result.err.Error())
+	}
+
+	if err := stepper.recordExecution(ctx, req, batch, startedAt, lastStep, completed, result); err != nil {
+		logger.Error(err, "Failed to record the execution in the status")
+		if result.err == nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return result.Normal()
 }
@@ -0,0 +1,48 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestStepperMetrics(t *testing.T) {
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithControllerName("test-metrics"),
+		library.WithStep(library.NewStep("First", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			return library.ResultSuccess()
+		})),
+		library.WithStep(library.NewStep("Second", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			return library.ResultRequeueIn(time.Second)
+		})),
+		library.WithStep(library.NewStep("Third", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			return library.ResultSuccess()
+		})),
+	)
+
+	result, err := stepper.Execute(context.Background(), ctrl.Request{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsZero() {
+		t.Errorf("expected the stepper to requeue")
+	}
+
+	if v := testutil.ToFloat64(library.StepOutcomeTotal.WithLabelValues("test-metrics", "First", library.StepOutcomeSuccess)); v != 1 {
+		t.Errorf("expected 1 success for First, got %v", v)
+	}
+	if v := testutil.ToFloat64(library.StepOutcomeTotal.WithLabelValues("test-metrics", "Second", library.StepOutcomeRequeue)); v != 1 {
+		t.Errorf("expected 1 requeue for Second, got %v", v)
+	}
+	if v := testutil.CollectAndCount(library.StepDuration, "library_stepper_step_duration_seconds"); v < 2 {
+		t.Errorf("expected the duration of at least 2 steps to be observed, got %v", v)
+	}
+	if v := testutil.ToFloat64(library.StepOutcomeTotal.WithLabelValues("test-metrics", "Third", library.StepOutcomeSuccess)); v != 0 {
+		t.Errorf("Third should not have been executed")
+	}
+}
@@ -0,0 +1,94 @@
+package library
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	TracerName = "library"
+
+	AttributeController = "library.controller"
+	AttributeStep       = "library.step"
+	AttributeResult     = "library.result"
+	AttributeGVK        = "library.gvk"
+	AttributeName       = "library.name"
+	AttributeNamespace  = "library.namespace"
+)
+
+func tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// SetupTracing installs a global tracer provider exporting spans to the given
+// OTLP gRPC endpoint. When the endpoint is empty, tracing stays disabled and
+// the returned shutdown function does nothing.
+func SetupTracing(ctx context.Context, serviceName string, endpoint string, insecure bool) (shutdown func(context.Context) error, err error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(endpoint),
+	}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create OTLP trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create tracing resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// setSpanObject adds the GVK, name and namespace of the object handled by the
+// current step to its span.
+func setSpanObject(ctx context.Context, gvk schema.GroupVersionKind, name string, namespace string) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String(AttributeGVK, gvk.String()),
+		attribute.String(AttributeName, name),
+		attribute.String(AttributeNamespace, namespace),
+	)
+}
+
+// endSpan records the result of a step on its span and ends it.
+func endSpan(span trace.Span, result StepResult) {
+	span.SetAttributes(attribute.String(AttributeResult, result.Outcome()))
+	if result.err != nil {
+		span.RecordError(result.err)
+		span.SetStatus(codes.Error, result.err.Error())
+	}
+	span.End()
+}
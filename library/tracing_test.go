@@ -0,0 +1,64 @@
+package library_test
+
+import (
+	"context"
+	"errors"
+	"library"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestStepperTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	defer provider.Shutdown(context.Background())
+
+	stepper := library.NewStepper(logr.Discard(),
+		library.WithControllerName("test-tracing"),
+		library.WithStep(library.NewStep("First", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			return library.ResultSuccess()
+		})),
+		library.WithStep(library.NewStep("Second", func(ctx context.Context, req ctrl.Request) library.StepResult {
+			return library.ResultInError(errors.New("boom"))
+		})),
+	)
+
+	_, err := stepper.Execute(context.Background(), ctrl.Request{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	var root tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == "Reconcile" {
+			root = span
+		}
+	}
+	if !root.SpanContext.IsValid() {
+		t.Fatal("expected a Reconcile root span")
+	}
+
+	for _, span := range spans {
+		if span.Name == "Reconcile" {
+			continue
+		}
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("step span %s should be a child of the Reconcile span", span.Name)
+		}
+		if span.Name == "Second" && span.Status.Code != codes.Error {
+			t.Errorf("step span %s should be in error", span.Name)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package library
 
 import (
 	"context"
+	"sync"
 
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
@@ -18,34 +21,142 @@ func SetupWatch[
 	isDependency bool,
 ) func(ctx context.Context, req ctrl.Request) StepResult {
 	return func(ctx context.Context, req ctrl.Request) StepResult {
-		// Setup watch if not already set
-		watchSource := NewWatchKey(object, CacheTypeEnqueueForOwner)
-		if !reconciler.IsWatchingSource(watchSource) {
-			requestHandler := handler.EnqueueRequestForOwner(reconciler.GetScheme(), reconciler.GetRESTMapper(), reconciler.GetCustomResource())
-			if isDependency {
-				managedByHandler, err := GetManagedByReconcileRequests(reconciler.GetCustomResource(), reconciler.GetScheme())
-				if err != nil {
-					return ResultInError(errors.Wrap(err, "failed to add watch source"))
-				}
-
-				requestHandler = handler.EnqueueRequestsFromMapFunc(managedByHandler)
+		gvk, err := apiutil.GVKForObject(object, reconciler.Scheme())
+		if err != nil {
+			return ResultInError(errors.Wrap(err, "failed to get watched resource kind"))
+		}
+
+		watchType := CacheTypeEnqueueForOwner
+		if isDependency {
+			watchType = CacheTypeEnqueueForManagedBy
+		}
+
+		err = watchKind(ctx, reconciler, req, NewWatchKey(gvk, watchType), object, func() (handler.EventHandler, error) {
+			if !isDependency {
+				return handler.EnqueueRequestForOwner(reconciler.Scheme(), reconciler.RESTMapper(), reconciler.GetCustomResource(ctx)), nil
 			}
 
-			// Add the watch source to the reconciler
-			err := reconciler.GetController().Watch(
-				source.Kind(
-					reconciler.GetCache(),
-					object,
-					requestHandler,
-				),
-			)
+			managedByHandler, err := GetDependentsReconcileRequests(reconciler, reconciler.Scheme(), reconciler.GetCustomResource(ctx))
 			if err != nil {
-				return ResultInError(errors.Wrap(err, "failed to add watch source"))
+				return nil, err
 			}
-
-			reconciler.AddWatchSource(watchSource)
+			return handler.EnqueueRequestsFromMapFunc(managedByHandler), nil
+		})
+		if err != nil {
+			return ResultInError(err)
 		}
 
 		return ResultSuccess()
 	}
 }
+
+// watchKind watches the kind of the object with the handler, unless the watch
+// source already exists, and records that the request uses it.
+func watchKind[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	req reconcile.Request,
+	key WatchCacheKey,
+	object client.Object,
+	newHandler func() (handler.EventHandler, error),
+) error {
+	lock := reconciler.WatchSetupLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	if !reconciler.IsWatchingSource(key) {
+		// Unstructured objects need their kind to be watched
+		watched := NewInstanceOf(object)
+		watched.GetObjectKind().SetGroupVersionKind(key.GVK)
+
+		requestHandler, err := newHandler()
+		if err != nil {
+			return errors.Wrap(err, "failed to add watch source")
+		}
+
+		err = reconciler.GetController().Watch(
+			source.Kind(
+				reconciler.GetCache(),
+				watched,
+				requestHandler,
+			),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to add watch source")
+		}
+
+		reconciler.AddWatchSource(key, watched)
+	}
+
+	reconciler.AcquireWatchSource(key, req)
+	GetStateValue[usedWatches](ctx).add(key)
+
+	return nil
+}
+
+// usedWatches holds the watch sources used by a reconciliation.
+type usedWatches struct {
+	lock sync.Mutex
+	keys map[WatchCacheKey]bool
+}
+
+func (watches *usedWatches) add(key WatchCacheKey) {
+	watches.lock.Lock()
+	defer watches.lock.Unlock()
+
+	if watches.keys == nil {
+		watches.keys = make(map[WatchCacheKey]bool)
+	}
+	watches.keys[key] = true
+}
+
+// releaseWatches forgets the watch sources used by the request, the informers of
+// the kinds that are not watched anymore are stopped.
+func releaseWatches[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	req reconcile.Request,
+) error {
+	lock := reconciler.WatchSetupLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, object := range reconciler.ReleaseWatchSources(req) {
+		if err := reconciler.GetCache().RemoveInformer(ctx, object); err != nil {
+			return errors.Wrap(err, "failed to remove informer")
+		}
+	}
+
+	return nil
+}
+
+// releaseUnusedWatches forgets the watch sources the request used before and did
+// not use in this reconciliation, e.g. the kind of a child that is not generated
+// anymore.
+func releaseUnusedWatches[
+	ControllerResourceType ControllerResource,
+](
+	ctx context.Context,
+	reconciler Reconciler[ControllerResourceType],
+	req reconcile.Request,
+) error {
+	lock := reconciler.WatchSetupLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	watches := GetStateValue[usedWatches](ctx)
+	watches.lock.Lock()
+	defer watches.lock.Unlock()
+
+	for _, object := range reconciler.ReleaseUnusedWatchSources(req, watches.keys) {
+		if err := reconciler.GetCache().RemoveInformer(ctx, object); err != nil {
+			return errors.Wrap(err, "failed to remove informer")
+		}
+	}
+
+	return nil
+}
@@ -1,40 +1,262 @@
 package library
 
-import "sigs.k8s.io/controller-runtime/pkg/client"
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
 
-type WatchCacheKey string
 type WatchCacheType string
 
 const (
-	CacheTypeEnqueueForOwner WatchCacheType = "enqueueForOwner"
+	CacheTypeEnqueueForOwner     WatchCacheType = "enqueueForOwner"
+	CacheTypeEnqueueForManagedBy WatchCacheType = "enqueueForManagedBy"
+	CacheTypeEnqueueForPending   WatchCacheType = "enqueueForPending"
+	CacheTypeEnqueueForSelector  WatchCacheType = "enqueueForSelector"
 )
 
+// WatchCacheKey identifies a watch on a kind, there is one for each handler type.
+type WatchCacheKey struct {
+	GVK  schema.GroupVersionKind
+	Type WatchCacheType
+}
+
+// DependencyKey identifies a dependency that may not exist yet.
+type DependencyKey struct {
+	GVK schema.GroupVersionKind
+	types.NamespacedName
+}
+
+// SelectorKey identifies the objects of a kind matching a label selector in a
+// namespace, an empty namespace matches every namespace.
+type SelectorKey struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Selector  string
+}
+
 type Watcher interface {
-	// AddWatchSource adds a watch source to the cache
-	AddWatchSource(key WatchCacheKey)
+	// WatchSetupLock returns the lock serializing the setup and the release of the
+	// watch sources, as children and dependencies are reconciled concurrently
+	WatchSetupLock() sync.Locker
+	// AddWatchSource adds a watch source on the kind of the object to the cache
+	AddWatchSource(key WatchCacheKey, obj client.Object)
 	// IsWatchSource checks if the key is a watch source
 	IsWatchingSource(key WatchCacheKey) bool
+	// AcquireWatchSource records that the request uses the watch source
+	AcquireWatchSource(key WatchCacheKey, req reconcile.Request)
+	// ReleaseWatchSources forgets the watch sources, pending dependencies and
+	// selectors of the request. It returns the objects of the kinds that no watch
+	// source uses anymore, their informers can be removed.
+	ReleaseWatchSources(req reconcile.Request) []client.Object
+	// ReleaseUnusedWatchSources forgets the watch sources of the request that are
+	// not in used. It returns the objects of the kinds that no watch source uses
+	// anymore, their informers can be removed.
+	ReleaseUnusedWatchSources(req reconcile.Request, used map[WatchCacheKey]bool) []client.Object
+	// AddPendingDependency records that the request waits for the dependency to exist
+	AddPendingDependency(dependency DependencyKey, req reconcile.Request)
+	// RemovePendingDependency forgets that the request waits for the dependency
+	RemovePendingDependency(dependency DependencyKey, req reconcile.Request)
+	// PendingRequests returns the requests waiting for the dependency
+	PendingRequests(dependency DependencyKey) []reconcile.Request
+	// SetSelectorDependencies records the selectors the request depends on, replacing the previous ones
+	SetSelectorDependencies(req reconcile.Request, selectors []SelectorKey)
+	// SelectorRequests returns the requests depending on a selector matching the object
+	SelectorRequests(gvk schema.GroupVersionKind, obj client.Object) []reconcile.Request
 }
 
+// watchSource is a watch registered on the controller, with the requests using it.
+type watchSource struct {
+	object   client.Object
+	requests map[reconcile.Request]bool
+}
+
+// WatchCache is safe for concurrent use by the workers of the controller.
 type WatchCache struct {
-	cache map[WatchCacheKey]bool
+	setup   sync.Mutex
+	lock    sync.RWMutex
+	cache   map[WatchCacheKey]*watchSource
+	pending map[DependencyKey]map[reconcile.Request]bool
+	// selectors are parsed once, the keys carry their string form
+	selectors map[SelectorKey]map[reconcile.Request]labels.Selector
+}
+
+func NewWatchKey(gvk schema.GroupVersionKind, watchType WatchCacheType) WatchCacheKey {
+	return WatchCacheKey{GVK: gvk, Type: watchType}
 }
 
-func NewWatchKey(obj client.Object, watchType WatchCacheType) WatchCacheKey {
-	return WatchCacheKey(obj.GetName() + "/" + string(watchType))
+func (w *WatchCache) WatchSetupLock() sync.Locker {
+	return &w.setup
 }
 
-func (w *WatchCache) AddWatchSource(key WatchCacheKey) {
+func (w *WatchCache) AddWatchSource(key WatchCacheKey, obj client.Object) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
 	if w.cache == nil {
-		w.cache = make(map[WatchCacheKey]bool)
+		w.cache = make(map[WatchCacheKey]*watchSource)
+	}
+	if _, ok := w.cache[key]; !ok {
+		w.cache[key] = &watchSource{object: obj, requests: make(map[reconcile.Request]bool)}
 	}
-	w.cache[key] = true
 }
 
 func (w *WatchCache) IsWatchingSource(key WatchCacheKey) bool {
-	if w.cache == nil {
-		return false
-	}
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
 	_, ok := w.cache[key]
 	return ok
 }
+
+func (w *WatchCache) AcquireWatchSource(key WatchCacheKey, req reconcile.Request) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if source, ok := w.cache[key]; ok {
+		source.requests[req] = true
+	}
+}
+
+func (w *WatchCache) ReleaseWatchSources(req reconcile.Request) []client.Object {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for dependency, requests := range w.pending {
+		delete(requests, req)
+		if len(requests) == 0 {
+			delete(w.pending, dependency)
+		}
+	}
+	for selector, requests := range w.selectors {
+		delete(requests, req)
+		if len(requests) == 0 {
+			delete(w.selectors, selector)
+		}
+	}
+
+	return w.release(req, nil)
+}
+
+func (w *WatchCache) ReleaseUnusedWatchSources(req reconcile.Request, used map[WatchCacheKey]bool) []client.Object {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.release(req, used)
+}
+
+// release forgets the watch sources of the request that are not used. It must
+// be called with the lock held.
+func (w *WatchCache) release(req reconcile.Request, used map[WatchCacheKey]bool) []client.Object {
+	var unused []client.Object
+	for key, source := range w.cache {
+		if !source.requests[req] || used[key] {
+			continue
+		}
+
+		delete(source.requests, req)
+		if len(source.requests) > 0 {
+			continue
+		}
+		delete(w.cache, key)
+
+		// The informer is shared by the watch sources of the kind
+		if !w.isWatchingKind(key.GVK) {
+			unused = append(unused, source.object)
+		}
+	}
+	return unused
+}
+
+func (w *WatchCache) isWatchingKind(gvk schema.GroupVersionKind) bool {
+	for key := range w.cache {
+		if key.GVK == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WatchCache) AddPendingDependency(dependency DependencyKey, req reconcile.Request) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.pending == nil {
+		w.pending = make(map[DependencyKey]map[reconcile.Request]bool)
+	}
+	if w.pending[dependency] == nil {
+		w.pending[dependency] = make(map[reconcile.Request]bool)
+	}
+	w.pending[dependency][req] = true
+}
+
+func (w *WatchCache) RemovePendingDependency(dependency DependencyKey, req reconcile.Request) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.pending[dependency], req)
+	if len(w.pending[dependency]) == 0 {
+		delete(w.pending, dependency)
+	}
+}
+
+func (w *WatchCache) PendingRequests(dependency DependencyKey) []reconcile.Request {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	requests := make([]reconcile.Request, 0, len(w.pending[dependency]))
+	for req := range w.pending[dependency] {
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+func (w *WatchCache) SetSelectorDependencies(req reconcile.Request, selectors []SelectorKey) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for key, requests := range w.selectors {
+		delete(requests, req)
+		if len(requests) == 0 {
+			delete(w.selectors, key)
+		}
+	}
+
+	for _, key := range selectors {
+		selector, err := labels.Parse(key.Selector)
+		if err != nil {
+			continue
+		}
+
+		if w.selectors == nil {
+			w.selectors = make(map[SelectorKey]map[reconcile.Request]labels.Selector)
+		}
+		if w.selectors[key] == nil {
+			w.selectors[key] = make(map[reconcile.Request]labels.Selector)
+		}
+		w.selectors[key][req] = selector
+	}
+}
+
+func (w *WatchCache) SelectorRequests(gvk schema.GroupVersionKind, obj client.Object) []reconcile.Request {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	var requests []reconcile.Request
+	for key, selectors := range w.selectors {
+		if key.GVK != gvk || (key.Namespace != "" && key.Namespace != obj.GetNamespace()) {
+			continue
+		}
+
+		for req, selector := range selectors {
+			if selector.Matches(labels.Set(obj.GetLabels())) {
+				requests = append(requests, req)
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,169 @@
+package library_test
+
+import (
+	"context"
+	"library"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1 "multi.ch/app/api/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestWatchCache(t *testing.T) {
+	first := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "default", UID: "first-uid"}}
+	second := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "default", UID: "second-uid"}}
+	reconciler := newTestReconciler(t, first, second)
+
+	// The children have the same name and different kinds
+	children := []library.GenericChildResource{
+		library.NewChildResource(
+			&corev1.ConfigMap{},
+			library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+				return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}, false, nil
+			}),
+		),
+		library.NewChildResource(
+			&corev1.Service{},
+			library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.Service, bool, error) {
+				return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}, false, nil
+			}),
+		),
+	}
+
+	execute := func(app *appv1.App) {
+		options := []library.StepperOptions{
+			library.WithReconciler(reconciler),
+			library.WithStep(library.NewFindControllerResourceStep(reconciler)),
+		}
+		for _, child := range children {
+			options = append(options, library.WithStep(library.NewReconcileChildStep(reconciler, child)))
+		}
+
+		stepper := library.NewStepper(logr.Discard(), options...)
+		if _, err := stepper.Execute(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remove := func(app *appv1.App) {
+		var current appv1.App
+		if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &current); err != nil {
+			t.Fatal(err)
+		}
+		current.Finalizers = nil
+		if err := reconciler.Update(context.Background(), &current); err != nil {
+			t.Fatal(err)
+		}
+		if err := reconciler.Delete(context.Background(), &current); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	execute(first)
+	execute(second)
+	execute(first)
+	if reconciler.controller.watches != 2 {
+		t.Errorf("expected a watch for each kind, got %d", reconciler.controller.watches)
+	}
+	for _, kind := range []string{"ConfigMap", "Service"} {
+		key := library.NewWatchKey(schema.GroupVersionKind{Version: "v1", Kind: kind}, library.CacheTypeEnqueueForOwner)
+		if !reconciler.IsWatchingSource(key) {
+			t.Errorf("expected the %s kind to be watched", kind)
+		}
+	}
+
+	// The Service is not generated anymore, its watch is released once no app uses it
+	children = children[:1]
+	service := library.NewWatchKey(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, library.CacheTypeEnqueueForOwner)
+	execute(first)
+	if !reconciler.IsWatchingSource(service) {
+		t.Error("expected the Service kind to be watched for the second app")
+	}
+	execute(second)
+	if reconciler.IsWatchingSource(service) || len(reconciler.cache.removed) != 1 {
+		t.Errorf("expected the Service informer to be removed, got %d removed", len(reconciler.cache.removed))
+	}
+
+	// The second app still needs the watch
+	remove(first)
+	execute(first)
+	if len(reconciler.cache.removed) != 1 {
+		t.Errorf("expected the ConfigMap informer to be kept, got %d removed", len(reconciler.cache.removed))
+	}
+
+	remove(second)
+	execute(second)
+	if len(reconciler.cache.removed) != 2 {
+		t.Errorf("expected the informers of both kinds to be removed, got %d", len(reconciler.cache.removed))
+	}
+	if reconciler.IsWatchingSource(library.NewWatchKey(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, library.CacheTypeEnqueueForOwner)) {
+		t.Error("expected the watch source to be forgotten")
+	}
+}
+
+func TestWatchReleasedWithoutFinalizer(t *testing.T) {
+	app := &appv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "app-uid"}}
+	key := library.NewWatchKey(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, library.CacheTypeEnqueueForOwner)
+
+	var reconciler *testReconciler
+	reconciler = newInterceptedTestReconciler(t, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, objKey client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			// The child is read from the cache once its informer is referenced
+			if _, ok := obj.(*corev1.ConfigMap); ok && !reconciler.IsWatchingSource(key) {
+				t.Error("expected the watch to be set up before the child is read")
+			}
+			return c.Get(ctx, objKey, obj, opts...)
+		},
+	}, app)
+
+	child := library.NewChildResource(
+		&corev1.ConfigMap{},
+		library.WithChildGenerator(func(ctx context.Context, req ctrl.Request) (*corev1.ConfigMap, bool, error) {
+			return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}, false, nil
+		}),
+	)
+	execute := func() {
+		stepper := library.NewStepper(logr.Discard(),
+			library.WithReconciler(reconciler),
+			library.WithStep(library.NewFindControllerResourceStep(reconciler)),
+			library.WithStep(library.NewReconcileChildStep(reconciler, child)),
+		)
+		if _, err := stepper.Execute(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(app)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	execute()
+	if !reconciler.IsWatchingSource(key) {
+		t.Fatal("expected the ConfigMap kind to be watched")
+	}
+
+	// The finalizer is removed out-of-band, another one keeps the app around
+	var current appv1.App
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &current); err != nil {
+		t.Fatal(err)
+	}
+	current.Finalizers = []string{"other.multi.ch/finalizer"}
+	if err := reconciler.Update(context.Background(), &current); err != nil {
+		t.Fatal(err)
+	}
+	if err := reconciler.Delete(context.Background(), &current); err != nil {
+		t.Fatal(err)
+	}
+
+	execute()
+	if reconciler.IsWatchingSource(key) || len(reconciler.cache.removed) != 1 {
+		t.Errorf("expected the ConfigMap informer to be removed, got %d removed", len(reconciler.cache.removed))
+	}
+	if err := reconciler.Get(context.Background(), client.ObjectKeyFromObject(app), &current); err != nil {
+		t.Fatal(err)
+	}
+	if len(current.Finalizers) != 1 {
+		t.Errorf("expected no finalizer to be added back, got %v", current.Finalizers)
+	}
+}
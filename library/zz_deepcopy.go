@@ -11,6 +11,17 @@ func (child *ObjectReference) DeepCopyInto(out *ObjectReference) {
 	out.UID = child.UID
 	out.Status = child.Status
 	out.Reason = child.Reason
+	if child.DriftedFields != nil {
+		out.DriftedFields = make([]string, len(child.DriftedFields))
+		copy(out.DriftedFields, child.DriftedFields)
+	}
+	if child.RecreatedAt != nil {
+		out.RecreatedAt = child.RecreatedAt.DeepCopy()
+	}
+	if child.RecreatedFields != nil {
+		out.RecreatedFields = make([]string, len(child.RecreatedFields))
+		copy(out.RecreatedFields, child.RecreatedFields)
+	}
 }
 
 func (child *ObjectReference) DeepCopy() *ObjectReference {
@@ -54,6 +65,32 @@ func (status *Status) DeepCopyInto(out *Status) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if status.Dependencies != nil {
+		in, out := &status.Dependencies, &out.Dependencies
+		*out = make(ObjectReferenceList, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if status.OrphanedResources != nil {
+		in, out := &status.OrphanedResources, &out.OrphanedResources
+		*out = make(ObjectReferenceList, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if status.History != nil {
+		in, out := &status.History, &out.History
+		*out = make([]ReconcileRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func (record *ReconcileRecord) DeepCopyInto(out *ReconcileRecord) {
+	*out = *record
+	record.StartTime.DeepCopyInto(&out.StartTime)
 }
 
 func (status *Status) DeepCopy() *Status {
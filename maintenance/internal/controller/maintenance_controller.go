@@ -23,6 +23,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -34,6 +35,9 @@ import (
 	routev1 "multi.ch/route/api/v1"
 )
 
+// FieldManager owns the fields the operator applies on the children
+const FieldManager = "maintenance-operator"
+
 // MaintenanceReconciler reconciles a Maintenance object
 type MaintenanceReconciler struct {
 	ctrl.Manager
@@ -43,8 +47,12 @@ type MaintenanceReconciler struct {
 	RuntimeScheme *runtime.Scheme
 	controller    controller.TypedController[reconcile.Request]
 
-	maintenance maintenancev1.Maintenance
+	// MaxConcurrentReconciles is the number of workers of the controller
+	MaxConcurrentReconciles int
+}
 
+// maintenanceState holds the children of a reconciliation
+type maintenanceState struct {
 	backend envoyapiv1alpha1.Backend
 }
 
@@ -58,20 +66,25 @@ func (reconciler *MaintenanceReconciler) GetFinalizer() string {
 	return "maintenance.multi.ch/finalizer"
 }
 
-func (reconciler *MaintenanceReconciler) GetCustomResource() *maintenancev1.Maintenance {
-	return &reconciler.maintenance
+func (reconciler *MaintenanceReconciler) GetCustomResource(ctx context.Context) *maintenancev1.Maintenance {
+	return library.GetCustomResource[*maintenancev1.Maintenance](ctx)
+}
+
+func (reconciler *MaintenanceReconciler) SetCustomResource(ctx context.Context, maintenance *maintenancev1.Maintenance) {
+	library.SetCustomResource(ctx, maintenance)
 }
 
-func (reconciler *MaintenanceReconciler) SetCustomResource(maintenance *maintenancev1.Maintenance) {
-	reconciler.maintenance = *maintenance
+func (reconciler *MaintenanceReconciler) state(ctx context.Context) *maintenanceState {
+	return library.GetStateValue[maintenanceState](ctx)
 }
 
 func (reconciler *MaintenanceReconciler) GetChildren(ctx context.Context, req ctrl.Request) ([]library.GenericChildResource, error) {
 	return []library.GenericChildResource{
 		library.NewChildResource(
 			&envoyapiv1alpha1.Backend{},
-			library.WithChildOutput(&reconciler.backend),
+			library.WithChildOutput(&reconciler.state(ctx).backend),
 			library.WithChildGenerator(reconciler.backendGenerator),
+			library.WithServerSideApply[*envoyapiv1alpha1.Backend](FieldManager),
 		),
 	}, nil
 }
@@ -86,9 +99,12 @@ func (reconciler *MaintenanceReconciler) Reconcile(ctx context.Context, req ctrl
 	logger := logf.FromContext(ctx)
 
 	stepper := library.NewStepper(logger,
+		library.WithControllerName("maintenance"),
+		library.WithReconciler(reconciler),
+		library.WithInterceptor(library.RecoverInterceptor()),
 		library.WithStep(library.NewFindControllerResourceStep(reconciler)),
 		library.WithStep(library.NewReconcileChildrenStep(reconciler)),
-		library.WithStep(reconciler.NewFillContractStep()),
+		library.WithStepOutsideFinalization(reconciler, reconciler.NewFillContractStep()),
 		library.WithStep(library.NewEndStep(reconciler)),
 	)
 
@@ -99,9 +115,15 @@ func (reconciler *MaintenanceReconciler) Reconcile(ctx context.Context, req ctrl
 func (reconciler *MaintenanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	reconciler.Manager = mgr
 
+	// The events of the dependencies are mapped to the controller resources depending on them
+	if err := library.IndexDependencies(context.Background(), mgr.GetFieldIndexer(), &maintenancev1.Maintenance{}); err != nil {
+		return err
+	}
+
 	controller, err := ctrl.NewControllerManagedBy(mgr).
-		For(&maintenancev1.Maintenance{}).
+		For(&maintenancev1.Maintenance{}, builder.WithPredicates(library.ControllerResourcePredicate())).
 		Named("maintenance").
+		WithOptions(controller.Options{MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles}).
 		Build(reconciler)
 	if err != nil {
 		return err
@@ -113,10 +135,12 @@ func (reconciler *MaintenanceReconciler) SetupWithManager(mgr ctrl.Manager) erro
 }
 
 func (reconciler *MaintenanceReconciler) backendGenerator(ctx context.Context, req ctrl.Request) (*envoyapiv1alpha1.Backend, bool, error) {
+	maintenance := reconciler.GetCustomResource(ctx)
+
 	return &envoyapiv1alpha1.Backend{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      reconciler.maintenance.Name,
-			Namespace: reconciler.maintenance.Namespace,
+			Name:      maintenance.Name,
+			Namespace: maintenance.Namespace,
 		},
 		Spec: envoyapiv1alpha1.BackendSpec{
 			Endpoints: []envoyapiv1alpha1.BackendEndpoint{
@@ -135,20 +159,24 @@ func (reconciler *MaintenanceReconciler) NewFillContractStep() library.Step {
 	return library.Step{
 		Name: "Fill Contract",
 		Step: func(ctx context.Context, req ctrl.Request) library.StepResult {
+			maintenance := reconciler.GetCustomResource(ctx)
+
 			newContract := routev1.RouteContract{
 				BackendRef: &routev1.RouteContractLocalBackendRef{
-					Name: reconciler.maintenance.Name,
+					Name: maintenance.Name,
 					Port: 80,
 				},
 			}
 
-			if reflect.DeepEqual(reconciler.maintenance.Status.RouteContractInjector.RouteContract, newContract) {
-				return library.ResultSuccess()
-			}
-
-			reconciler.maintenance.Status.RouteContractInjector.RouteContract = newContract
+			err := library.UpdateStatus(ctx, reconciler, func(status *library.Status) bool {
+				if reflect.DeepEqual(maintenance.Status.RouteContractInjector.RouteContract, newContract) {
+					return false
+				}
 
-			if err := reconciler.Status().Update(ctx, &reconciler.maintenance); err != nil {
+				maintenance.Status.RouteContractInjector.RouteContract = newContract
+				return true
+			})
+			if err != nil {
 				return library.ResultInError(err)
 			}
 
@@ -18,12 +18,24 @@ type RouteSpec struct {
 	TargetRefs []*RouteTargetReference `json:"targetRefs,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="has(self.name) != has(self.selector)",message="exactly one of name and selector must be set"
 type RouteTargetReference struct {
 	APIVersion string `json:"apiVersion"`
 	Kind       string `json:"kind"`
-	Name       string `json:"name"`
+
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Selector targets every object of the kind matching the labels in the
+	// namespace of the route
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
 	PathPrefix string `json:"pathPrefix"`
+
+	// Optional targets that do not exist are left out of the route
+	// +optional
+	Optional bool `json:"optional,omitempty"`
 }
 
 // RouteStatus defines the observed state of Route.
@@ -0,0 +1,98 @@
+// Command plan prints the changes the route operator would perform on the
+// children of a Route, without writing anything.
+//
+//	plan -namespace default -name route-sample   plans the live spec
+//	plan -f route.yaml                             plans the spec of the file
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	routev1 "multi.ch/route/api/v1"
+	"multi.ch/route/internal/controller"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(routev1.AddToScheme(scheme))
+	utilruntime.Must(gatewayv1.Install(scheme))
+}
+
+func main() {
+	var file, name, namespace string
+	flag.StringVar(&file, "f", "", "A file containing the proposed Route, its spec replaces the live one.")
+	flag.StringVar(&name, "name", "", "The name of the Route to plan.")
+	flag.StringVar(&namespace, "namespace", "default", "The namespace of the Route to plan.")
+	flag.Parse()
+
+	if err := run(context.Background(), file, name, namespace); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, file, name, namespace string) error {
+	var proposed *routev1.Route
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		proposed = &routev1.Route{}
+		if err := yaml.Unmarshal(data, proposed); err != nil {
+			return err
+		}
+
+		name = proposed.Name
+		if proposed.Namespace != "" {
+			namespace = proposed.Namespace
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("either -f or -name is required")
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	var route routev1.Route
+	err = cluster.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &route)
+	switch {
+	case apierrors.IsNotFound(err) && proposed != nil:
+		route = *proposed
+		route.Namespace = namespace
+	case err != nil:
+		return err
+	case proposed != nil:
+		route.Spec = proposed.Spec
+	}
+
+	plan, err := controller.Plan(ctx, cluster, &route)
+	if err != nil {
+		return err
+	}
+
+	return plan.Write(os.Stdout)
+}
@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"library"
 
-	"golang.org/x/exp/maps"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -21,6 +21,9 @@ import (
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
+// FieldManager owns the fields the operator applies on the children
+const FieldManager = "route-operator"
+
 // RouteReconciler reconciles a Route object
 type RouteReconciler struct {
 	ctrl.Manager
@@ -30,11 +33,15 @@ type RouteReconciler struct {
 	RuntimeScheme *runtime.Scheme
 	controller    controller.TypedController[reconcile.Request]
 
-	// CR
-	route routev1.Route
+	// MaxConcurrentReconciles is the number of workers of the controller
+	MaxConcurrentReconciles int
+}
 
+// routeState holds the dependencies and the children of a reconciliation
+type routeState struct {
 	// Dependencies
-	targets map[routev1.RouteTargetReference]*unstructured.Unstructured
+	targets   map[routev1.RouteTargetReference]*unstructured.Unstructured
+	selectors map[routev1.RouteTargetReference]*library.SelectorDependencyResource[*unstructured.Unstructured]
 
 	// Children
 	httproute gatewayv1.HTTPRoute
@@ -50,24 +57,28 @@ func (reconciler *RouteReconciler) GetFinalizer() string {
 	return "route.multi.ch/finalizer"
 }
 
-func (reconciler *RouteReconciler) GetCustomResource() *routev1.Route {
-	return &reconciler.route
+func (reconciler *RouteReconciler) GetCustomResource(ctx context.Context) *routev1.Route {
+	return library.GetCustomResource[*routev1.Route](ctx)
 }
 
-func (reconciler *RouteReconciler) SetCustomResource(route *routev1.Route) {
-	reconciler.route = *route
+func (reconciler *RouteReconciler) SetCustomResource(ctx context.Context, route *routev1.Route) {
+	library.SetCustomResource(ctx, route)
 }
 
-func (reconciler *RouteReconciler) GetDependencies(ctx context.Context, req ctrl.Request) (dependencies []library.GenericDependencyResource, err error) {
-	reconciler.targets = make(map[routev1.RouteTargetReference]*unstructured.Unstructured)
+func (reconciler *RouteReconciler) state(ctx context.Context) *routeState {
+	return library.GetStateValue[routeState](ctx)
+}
 
-	for _, target := range reconciler.route.Spec.TargetRefs {
-		var output unstructured.Unstructured
-		reconciler.targets[*target] = &output
+func (reconciler *RouteReconciler) GetDependencies(ctx context.Context, req ctrl.Request) (dependencies []library.GenericDependencyResource, err error) {
+	route := reconciler.GetCustomResource(ctx)
+	state := reconciler.state(ctx)
+	state.targets = make(map[routev1.RouteTargetReference]*unstructured.Unstructured)
+	state.selectors = make(map[routev1.RouteTargetReference]*library.SelectorDependencyResource[*unstructured.Unstructured])
 
+	for _, target := range route.Spec.TargetRefs {
 		gv, err := schema.ParseGroupVersion(target.APIVersion)
 		if err != nil {
-			return nil, err
+			return nil, library.NewTerminalError(library.ErrorCategoryInvalidSpec, err)
 		}
 
 		gvk := schema.GroupVersionKind{
@@ -76,11 +87,36 @@ func (reconciler *RouteReconciler) GetDependencies(ctx context.Context, req ctrl
 			Kind:    target.Kind,
 		}
 
+		if target.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(target.Selector)
+			if err != nil {
+				return nil, library.NewTerminalError(library.ErrorCategoryInvalidSpec, err)
+			}
+
+			dependency := library.NewUntypedSelectorDependencyResource(
+				gvk,
+				selector,
+				library.WithNamespace[*unstructured.Unstructured](route.Namespace),
+				library.WithWaitForReady[*unstructured.Unstructured](true),
+				library.WithMetadataOnly[*unstructured.Unstructured](true),
+			)
+			state.selectors[*target] = dependency
+
+			dependencies = append(dependencies, dependency)
+			continue
+		}
+
+		var output unstructured.Unstructured
+		state.targets[*target] = &output
+
 		dependency := library.NewUntypedDependencyResource(
 			gvk,
 			library.WithName[*unstructured.Unstructured](target.Name),
-			library.WithNamespace[*unstructured.Unstructured](reconciler.route.Namespace),
+			library.WithNamespace[*unstructured.Unstructured](route.Namespace),
 			library.WithOutput(&output),
+			library.WithWaitForReady[*unstructured.Unstructured](true),
+			library.WithOptional[*unstructured.Unstructured](target.Optional),
+			library.WithMetadataOnly[*unstructured.Unstructured](true),
 		)
 
 		dependencies = append(dependencies, dependency)
@@ -93,8 +129,9 @@ func (reconciler *RouteReconciler) GetChildren(ctx context.Context, req ctrl.Req
 	return []library.GenericChildResource{
 		library.NewChildResource(
 			&gatewayv1.HTTPRoute{},
-			library.WithChildOutput(&reconciler.httproute),
+			library.WithChildOutput(&reconciler.state(ctx).httproute),
 			library.WithChildGenerator(reconciler.httpRouteGenerator),
+			library.WithServerSideApply[*gatewayv1.HTTPRoute](FieldManager),
 		),
 	}, nil
 }
@@ -108,10 +145,15 @@ func (reconciler *RouteReconciler) GetChildren(ctx context.Context, req ctrl.Req
 
 // +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
 
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
 func (reconciler *RouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := logf.FromContext(ctx)
 
 	stepper := library.NewStepper(logger,
+		library.WithControllerName("route"),
+		library.WithReconciler(reconciler),
+		library.WithInterceptor(library.RecoverInterceptor()),
 		library.WithStep(library.NewFindControllerResourceStep(reconciler)),
 		library.WithStep(library.NewResolveDynamicDependenciesStep(reconciler)),
 		library.WithStep(library.NewReconcileChildrenStep(reconciler)),
@@ -122,35 +164,54 @@ func (reconciler *RouteReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 }
 
 func (reconciler *RouteReconciler) httpRouteGenerator(ctx context.Context, req ctrl.Request) (*gatewayv1.HTTPRoute, bool, error) {
+	route := reconciler.GetCustomResource(ctx)
+
 	var hostnames []gatewayv1.Hostname
-	for _, hostname := range reconciler.route.Spec.Hostnames {
+	for _, hostname := range route.Spec.Hostnames {
 		hostnames = append(hostnames, gatewayv1.Hostname(hostname))
 	}
 
 	var rules []gatewayv1.HTTPRouteRule
-	for i, targetRef := range maps.Keys(reconciler.targets) {
-		target := reconciler.targets[targetRef]
-
-		// Get the route contract from the target
-		routeContract, err := library.GetContract[routev1.RouteContract](target, "routeContract")
-		if err != nil {
-			return nil, false, err
+	for _, targetRef := range route.Spec.TargetRefs {
+		// The objects matching a selector share the rule of the target
+		var backendRefs []gatewayv1.HTTPBackendRef
+		for _, target := range reconciler.targetObjects(ctx, targetRef) {
+			// Absent optional targets and lost targets are left out
+			if !library.IsPresent(target) {
+				continue
+			}
+
+			// Get the route contract from the target
+			routeContract, err := library.GetContract[routev1.RouteContract](target, "routeContract")
+			if err != nil {
+				return nil, false, err
+			}
+
+			var backendRef gatewayv1.BackendObjectReference
+
+			if routeContract.ServiceRef != nil {
+				backendRef.Name = gatewayv1.ObjectName(routeContract.ServiceRef.Name)
+				backendRef.Port = library.Opt(gatewayv1.PortNumber(routeContract.ServiceRef.Port))
+			} else if routeContract.BackendRef != nil {
+				backendRef.Group = library.Opt(gatewayv1.Group(envoyapiv1alpha1.GroupName))
+				backendRef.Kind = library.Opt(gatewayv1.Kind("Backend"))
+				backendRef.Name = gatewayv1.ObjectName(routeContract.BackendRef.Name)
+				backendRef.Port = library.Opt(gatewayv1.PortNumber(routeContract.BackendRef.Port))
+			}
+
+			backendRefs = append(backendRefs, gatewayv1.HTTPBackendRef{
+				BackendRef: gatewayv1.BackendRef{
+					BackendObjectReference: backendRef,
+				},
+			})
 		}
 
-		var backendRef gatewayv1.BackendObjectReference
-
-		if routeContract.ServiceRef != nil {
-			backendRef.Name = gatewayv1.ObjectName(routeContract.ServiceRef.Name)
-			backendRef.Port = library.Opt(gatewayv1.PortNumber(routeContract.ServiceRef.Port))
-		} else if routeContract.BackendRef != nil {
-			backendRef.Group = library.Opt(gatewayv1.Group(envoyapiv1alpha1.GroupName))
-			backendRef.Kind = library.Opt(gatewayv1.Kind("Backend"))
-			backendRef.Name = gatewayv1.ObjectName(routeContract.BackendRef.Name)
-			backendRef.Port = library.Opt(gatewayv1.PortNumber(routeContract.BackendRef.Port))
+		if len(backendRefs) == 0 {
+			continue
 		}
 
 		rules = append(rules, gatewayv1.HTTPRouteRule{
-			Name: library.Opt(gatewayv1.SectionName(fmt.Sprintf("target.%d", i))),
+			Name: library.Opt(gatewayv1.SectionName(fmt.Sprintf("target.%d", len(rules)))),
 			Matches: []gatewayv1.HTTPRouteMatch{
 				{
 					Path: &gatewayv1.HTTPPathMatch{
@@ -159,20 +220,14 @@ func (reconciler *RouteReconciler) httpRouteGenerator(ctx context.Context, req c
 					},
 				},
 			},
-			BackendRefs: []gatewayv1.HTTPBackendRef{
-				{
-					BackendRef: gatewayv1.BackendRef{
-						BackendObjectReference: backendRef,
-					},
-				},
-			},
+			BackendRefs: backendRefs,
 		})
 	}
 
 	return &gatewayv1.HTTPRoute{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      reconciler.route.Name,
-			Namespace: reconciler.route.Namespace,
+			Name:      route.Name,
+			Namespace: route.Namespace,
 		},
 		Spec: gatewayv1.HTTPRouteSpec{
 			CommonRouteSpec: gatewayv1.CommonRouteSpec{
@@ -189,13 +244,32 @@ func (reconciler *RouteReconciler) httpRouteGenerator(ctx context.Context, req c
 	}, false, nil
 }
 
+// targetObjects returns the objects the target resolves to, a target with a selector
+// resolves to every object matching it.
+func (reconciler *RouteReconciler) targetObjects(ctx context.Context, targetRef *routev1.RouteTargetReference) []*unstructured.Unstructured {
+	state := reconciler.state(ctx)
+	if selector, ok := state.selectors[*targetRef]; ok {
+		return selector.Items()
+	}
+	if target, ok := state.targets[*targetRef]; ok {
+		return []*unstructured.Unstructured{target}
+	}
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (reconciler *RouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	reconciler.Manager = mgr
 
+	// The events of the targets are mapped to the routes depending on them
+	if err := library.IndexDependencies(context.Background(), mgr.GetFieldIndexer(), &routev1.Route{}); err != nil {
+		return err
+	}
+
 	controller, err := ctrl.NewControllerManagedBy(mgr).
-		For(&routev1.Route{}).
+		For(&routev1.Route{}, builder.WithPredicates(library.ControllerResourcePredicate())).
 		Named("route").
+		WithOptions(controller.Options{MaxConcurrentReconciles: reconciler.MaxConcurrentReconciles}).
 		Build(reconciler)
 	if err != nil {
 		return err
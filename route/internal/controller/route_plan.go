@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"context"
+	"library"
+
+	routev1 "multi.ch/route/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Plan computes the changes a reconciliation of the route would perform on its
+// children, without writing anything. The route may carry a spec that is not applied yet.
+func Plan(ctx context.Context, cluster client.Client, route *routev1.Route) (*library.Plan, error) {
+	reconciler := &RouteReconciler{
+		Manager: library.NewPlanManager(cluster),
+		Client:  cluster,
+	}
+
+	return library.NewPlan(ctx, reconciler, route)
+}